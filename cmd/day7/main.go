@@ -2,11 +2,11 @@ package main
 
 import (
 	"2019/internal/intcode"
-	"bufio"
+	"context"
+	"flag"
 	"fmt"
-	"io"
-	"strconv"
-	"strings"
+	"log"
+	"os"
 )
 
 // RunMode ...
@@ -19,130 +19,75 @@ const (
 	SimpleMode RunMode = 2
 )
 
-// Amplifier ...
-type Amplifier struct {
-	Data   []int
-	Input  io.Reader
-	Output io.Writer
+// RunSetting connects one amplifier per phase in a ring and returns the last
+// signal sent by the final amplifier. Without feedback the first amplifier has
+// halted before the signal comes back around, so the ring also covers the
+// simple mode. It returns an error if an amplifier fails or every amplifier
+// is left waiting for input.
+func RunSetting(setting []int, set []int) (int, error) {
+	signal, _, err := intcode.NewRing(set, setting, 0).Run(context.Background())
+	return signal, err
 }
 
-// RunSetting ...
-func RunSetting(setting []int, set []int) int {
-	amps := make([]*Amplifier, len(setting))
-
-	startReader, endWriter := io.Pipe()
-	for i := 0; i < len(amps); i++ {
-		a := make([]int, len(set))
-		copy(a, set)
-		amp := Amplifier{
-			Data: a}
-
-		if i == 0 {
-			amp.Input = startReader
-		} else {
-			reader, writer := io.Pipe()
-			amps[i-1].Output = writer
-			amp.Input = reader
-		}
-		amps[i] = &amp
-	}
-	amps[len(amps)-1].Output = endWriter
-
-	done := make(chan bool, len(setting)-1)
+// MaxSetting ...
+func MaxSetting(mode RunMode, codes []int) (int, []int, error) {
+	search := Search{Amplifiers: 5, Top: 1}
 
-	for _, s := range amps {
-		go func(amp *Amplifier) {
-			intcode.Process(amp.Input, amp.Output, 0, amp.Data)
-			done <- true
-		}(s)
+	switch mode {
+	case SimpleMode:
+		search.Min, search.Max = 0, 4
+	case FeedbackMode:
+		search.Min, search.Max = 5, 9
 	}
 
-	for i, val := range append(setting[1:], setting[0]) {
-		fmt.Fprint(amps[i].Output, fmt.Sprintf("%v\n", val))
+	top, err := search.Run(context.Background(), codes)
+	if err != nil {
+		return 0, nil, err
 	}
 
-	fmt.Fprint(amps[len(amps)-1].Output, "0\n")
+	return top[0].Signal, top[0].Setting, nil
+}
 
-	for i := 0; i < len(setting)-1; i++ {
-		<-done
-	}
+func main() {
+	report := flag.String("report", "", "write the signals of the best feedback setting to this CSV file")
+	flag.Parse()
 
-	reader := bufio.NewReader(startReader)
-	text, _ := reader.ReadString('\n')
+	codes := intcode.ReadCodes("./commands.txt")
 
-	j, err := strconv.Atoi(strings.TrimRight(text, "\n"))
+	max, _, err := MaxSetting(SimpleMode, codes)
 	if err != nil {
-		panic("non-int output")
+		log.Fatal(err)
 	}
+	println(fmt.Sprintf("max value simple mode: %v", max))
 
-	return j
-}
-
-// ValidSetting ...
-func ValidSetting(setting []int) bool {
-	codes := make(map[int]bool)
-
-	for _, i := range setting {
-		_, ok := codes[i]
-		if ok {
-			return false
-		}
-		codes[i] = true
+	max, setting, err := MaxSetting(FeedbackMode, codes)
+	if err != nil {
+		log.Fatal(err)
 	}
-	return true
-}
+	println(fmt.Sprintf("max value feedback mode: %v", max))
 
-// SettingsList ...
-func SettingsList(min int, max int) [][]int {
-	list := [][]int{}
-
-	for i := min; i <= max; i++ {
-		for j := min; j <= max; j++ {
-			for k := min; k <= max; k++ {
-				for l := min; l <= max; l++ {
-					for m := min; m <= max; m++ {
-						item := []int{i, j, k, l, m}
-						list = append(list, item)
-					}
-				}
-			}
-		}
+	if *report == "" {
+		return
 	}
 
-	return list
-}
-
-// MaxSetting ...
-func MaxSetting(mode RunMode, codes []int) (int, []int) {
-	max := 0
-	s := []int{0, 0, 0, 0, 0}
-	allSettings := [][]int{}
-
-	switch mode {
-	case SimpleMode:
-		allSettings = SettingsList(0, 4)
-	case FeedbackMode:
-		allSettings = SettingsList(5, 9)
+	r, err := RunSettingReport(context.Background(), setting, codes)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	for _, setting := range allSettings {
-		if ValidSetting(setting) {
-			result := RunSetting(setting, codes)
-			if result > max {
-				s = setting
-				max = result
-			}
-		}
+	file, err := os.Create(*report)
+	if err != nil {
+		log.Fatal(err)
 	}
+	defer file.Close()
 
-	return max, s
-}
-
-func main() {
-	codes := intcode.ReadCodes("./commands.txt")
+	err = r.WriteCSV(file)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	max, _ := MaxSetting(SimpleMode, codes)
-	println(fmt.Sprintf("max value simple mode: %v", max))
-	max, _ = MaxSetting(FeedbackMode, codes)
-	println(fmt.Sprintf("max value feedback mode: %v", max))
+	for i := range r.Setting {
+		println(fmt.Sprintf("amplifier %v: %v steps in %v", i, r.Steps[i], r.Durations[i]))
+	}
+	println(fmt.Sprintf("%v rounds in %v", r.Rounds(), r.Wall))
 }
@@ -1,9 +1,28 @@
 package main
 
 import (
+	"2019/internal/intcode"
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
 	"testing"
 )
 
+// distinct reports whether no phase appears twice in the setting
+func distinct(setting []int) bool {
+	seen := make(map[int]bool)
+	for _, phase := range setting {
+		if seen[phase] {
+			return false
+		}
+		seen[phase] = true
+	}
+
+	return true
+}
+
 func TestRunSetting_SimpleMode(t *testing.T) {
 	codes := [][]int{
 		[]int{3, 15, 3, 16, 1002, 16, 10, 16, 1, 16, 15, 15, 4, 15, 99, 0, 0},
@@ -17,7 +36,11 @@ func TestRunSetting_SimpleMode(t *testing.T) {
 	expected := []int{43210, 54321, 65210}
 
 	for i, set := range codes {
-		result := RunSetting(setting[i], set)
+		result, err := RunSetting(setting[i], set)
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			continue
+		}
 
 		if result != expected[i] {
 			t.Errorf("incorrect signal %v; expected %v", result, expected[i])
@@ -25,6 +48,19 @@ func TestRunSetting_SimpleMode(t *testing.T) {
 	}
 }
 
+func TestRunSetting_Error(t *testing.T) {
+	// reads the phase and signal without ever sending a signal on
+	_, err := RunSetting([]int{0, 1}, []int{3, 0, 3, 0, 3, 0, 99})
+	if err == nil {
+		t.Errorf("expected error when every amplifier waits for input")
+	}
+
+	_, _, err = MaxSetting(FeedbackMode, []int{3, 0, 3, 0, 3, 0, 99})
+	if err == nil {
+		t.Errorf("expected error from the search when every amplifier waits for input")
+	}
+}
+
 func TestMaxSetting_SimpleMode(t *testing.T) {
 	codes := [][]int{
 		[]int{3, 15, 3, 16, 1002, 16, 10, 16, 1, 16, 15, 15, 4, 15, 99, 0, 0},
@@ -34,7 +70,11 @@ func TestMaxSetting_SimpleMode(t *testing.T) {
 	expected := []int{43210, 54321, 65210}
 
 	for i, set := range codes {
-		max, _ := MaxSetting(SimpleMode, set)
+		max, _, err := MaxSetting(SimpleMode, set)
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			continue
+		}
 
 		if max != expected[i] {
 			t.Errorf("incorrect signal %v; expected %v", max, expected[i])
@@ -53,7 +93,11 @@ func TestRunSetting_FeedbackMode(t *testing.T) {
 	expected := []int{139629729, 18216}
 
 	for i, set := range codes {
-		result := RunSetting(setting[i], set)
+		result, err := RunSetting(setting[i], set)
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			continue
+		}
 
 		if result != expected[i] {
 			t.Errorf("incorrect signal %v; expected %v", result, expected[i])
@@ -69,7 +113,11 @@ func TestMaxSetting_FeedbackMode(t *testing.T) {
 	expected := []int{139629729, 18216}
 
 	for i, set := range codes {
-		max, _ := MaxSetting(FeedbackMode, set)
+		max, _, err := MaxSetting(FeedbackMode, set)
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			continue
+		}
 
 		if max != expected[i] {
 			t.Errorf("incorrect signal %v; expected %v", max, expected[i])
@@ -77,28 +125,160 @@ func TestMaxSetting_FeedbackMode(t *testing.T) {
 	}
 }
 
-func TestSettingsList(t *testing.T) {
-	expected := 3125
+func TestPermute(t *testing.T) {
+	inputs := [][]int{
+		[]int{},
+		[]int{1},
+		[]int{1, 2, 3},
+		[]int{0, 1, 2, 3, 4}}
+
+	expected := []int{1, 1, 6, 120}
 
-	result := SettingsList(0, 4)
+	for i, input := range inputs {
+		seen := make(map[string]bool)
+		Permute(input, func(p []int) {
+			if len(p) != len(input) || !distinct(p) {
+				t.Errorf("invalid permutation %v of %v", p, input)
+			}
+			seen[fmt.Sprint(p)] = true
+		})
 
-	if len(result) != expected {
-		t.Errorf("incorrect possible settings %v; expected %v", len(result), expected)
+		if len(seen) != expected[i] {
+			t.Errorf("incorrect permutations %v of %v; expected %v", len(seen), input, expected[i])
+		}
 	}
 }
 
-func TestValidSetting(t *testing.T) {
-	inputs := [][]int{
-		[]int{0, 1, 2, 3, 4},
-		[]int{2, 2, 3, 4, 1}}
+func TestSearch_Settings(t *testing.T) {
+	inputs := []Search{
+		Search{Min: 0, Max: 4, Amplifiers: 5},
+		Search{Min: 0, Max: 5, Amplifiers: 3},
+		Search{Min: 5, Max: 9, Amplifiers: 1},
+		Search{Min: 0, Max: 2, Amplifiers: 4}}
 
-	expected := []bool{true, false}
+	expected := []int{120, 120, 5, 0}
 
 	for i, input := range inputs {
-		valid := ValidSetting(input)
+		seen := make(map[string]bool)
+		input.Settings(func(setting []int) {
+			if len(setting) != input.Amplifiers || !distinct(setting) {
+				t.Errorf("invalid setting %v for test %v", setting, i+1)
+			}
+			seen[fmt.Sprint(setting)] = true
+		})
+
+		if len(seen) != expected[i] {
+			t.Errorf("incorrect settings %v for test %v; expected %v", len(seen), i+1, expected[i])
+		}
+	}
+}
+
+func TestSearch_Run(t *testing.T) {
+	// the signal is the setting read as decimal digits
+	codes := []int{3, 15, 3, 16, 1002, 16, 10, 16, 1, 16, 15, 15, 4, 15, 99, 0, 0}
+	expected := []Candidate{
+		Candidate{Setting: []int{4, 3, 2, 1, 0}, Signal: 43210},
+		Candidate{Setting: []int{4, 3, 2, 0, 1}, Signal: 43201},
+		Candidate{Setting: []int{4, 3, 1, 2, 0}, Signal: 43120}}
+
+	for _, workers := range []int{1, 4} {
+		search := Search{Min: 0, Max: 4, Amplifiers: 5, Workers: workers, Top: 3}
+		top, err := search.Run(context.Background(), codes)
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			continue
+		}
+
+		if len(top) != len(expected) {
+			t.Errorf("incorrect candidates %v; expected %v", top, expected)
+			continue
+		}
+
+		for i, c := range top {
+			if c.Signal != expected[i].Signal || fmt.Sprint(c.Setting) != fmt.Sprint(expected[i].Setting) {
+				t.Errorf("incorrect candidate %v with %v workers; expected %v", c, workers, expected[i])
+			}
+		}
+	}
+
+	_, err := Search{Min: 0, Max: 4, Amplifiers: 5}.Run(context.Background(), []int{3, 0, 55})
+	if err == nil {
+		t.Errorf("expected error from a failing program")
+	}
+}
+
+func TestSearch_Run_Deadlock(t *testing.T) {
+	// reads two values before its first output, so no amplifier ever
+	// receives a signal
+	codes := []int{3, 9, 3, 9, 3, 9, 4, 9, 99, 0}
+
+	before := runtime.NumGoroutine()
+	_, err := Search{Min: 0, Max: 4, Amplifiers: 5, Workers: 2}.Run(context.Background(), codes)
+
+	d, ok := err.(*intcode.DeadlockError)
+	if !ok {
+		t.Errorf("incorrect error %v; expected a deadlock", err)
+		return
+	}
+
+	if len(d.Stalls) != 5 {
+		t.Errorf("incorrect stalls %v; expected every amplifier", d.Stalls)
+		return
+	}
+
+	// the first amplifier has also read the initial signal
+	expected := []int{4, 2, 2, 2, 2}
+	for i, s := range d.Stalls {
+		if s.Node != i || s.Position != expected[i] {
+			t.Errorf("incorrect stall %+v; expected amplifier %v waiting at ip %v", s, i, expected[i])
+		}
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("incorrect goroutine count %v after search; expected at most %v", after, before)
+	}
+}
+
+func TestRunSettingReport(t *testing.T) {
+	codes := []int{3, 26, 1001, 26, -4, 26, 3, 27, 1002, 27, 2, 27, 1, 27, 26, 27, 4, 27, 1001, 28, -1, 28, 1005, 28, 6, 99, 0, 0, 5}
+	setting := []int{9, 8, 7, 6, 5}
+
+	r, err := RunSettingReport(context.Background(), setting, codes)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	if r.Signal != 139629729 || r.Rounds() != 5 || len(r.Signals) != 25 {
+		t.Errorf("incorrect report with signal %v over %v rounds and %v signals", r.Signal, r.Rounds(), len(r.Signals))
+		return
+	}
 
-		if valid != expected[i] {
-			t.Errorf("incorrect validity test %v on index %v; expected %v", valid, i, expected[i])
+	// each amplifier doubles the signal and adds its phase less four
+	for i := 1; i < len(r.Signals); i++ {
+		if r.Signals[i].Value <= r.Signals[i-1].Value {
+			t.Errorf("signal %v did not grow from %v", r.Signals[i], r.Signals[i-1])
 		}
 	}
+
+	if len(r.Steps) != 5 || r.Steps[0] == 0 {
+		t.Errorf("incorrect steps %v", r.Steps)
+	}
+
+	buf := new(bytes.Buffer)
+	err = r.WriteCSV(buf)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 26 || lines[0] != "round,amplifier,phase,signal,elapsed_us" {
+		t.Errorf("incorrect csv header %q with %v lines", lines[0], len(lines))
+		return
+	}
+
+	if !strings.HasPrefix(lines[25], "5,4,5,139629729,") {
+		t.Errorf("incorrect last row %q", lines[25])
+	}
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"2019/internal/intcode"
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Signal is a value sent by an amplifier in a feedback round. Rounds count
+// from one.
+type Signal struct {
+	Round     int
+	Amplifier int
+	Value     int
+	Elapsed   time.Duration
+}
+
+// Report records a run of the amplifiers. Steps and Durations hold the
+// instruction count and running time of each amplifier.
+type Report struct {
+	Setting   []int
+	Signal    int
+	Signals   []Signal
+	Steps     []int
+	Durations []time.Duration
+	Wall      time.Duration
+}
+
+// RunSettingReport runs the setting like RunSetting and records every signal
+// passed between the amplifiers
+func RunSettingReport(ctx context.Context, setting []int, set []int) (*Report, error) {
+	r := &Report{Setting: append([]int{}, setting...)}
+
+	ring := intcode.NewRing(set, setting, 0)
+	ring.Observe = func(e intcode.Emission) {
+		r.Signals = append(r.Signals, Signal{Round: e.Index, Amplifier: e.Node, Value: e.Value, Elapsed: e.Elapsed})
+	}
+
+	start := time.Now()
+	signal, stats, err := ring.Run(ctx)
+	r.Wall = time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Signal = signal
+	for _, s := range stats {
+		r.Steps = append(r.Steps, s.Steps)
+		r.Durations = append(r.Durations, s.Duration)
+	}
+
+	sort.SliceStable(r.Signals, func(i, j int) bool {
+		a, b := r.Signals[i], r.Signals[j]
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		return a.Amplifier < b.Amplifier
+	})
+
+	return r, nil
+}
+
+// Rounds returns the number of feedback rounds
+func (r *Report) Rounds() int {
+	rounds := 0
+	for _, s := range r.Signals {
+		if s.Round > rounds {
+			rounds = s.Round
+		}
+	}
+
+	return rounds
+}
+
+// WriteCSV writes one row per signal with the phase of the amplifier that sent
+// it and the time since the run started in microseconds
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"round", "amplifier", "phase", "signal", "elapsed_us"})
+
+	for _, s := range r.Signals {
+		cw.Write([]string{
+			strconv.Itoa(s.Round),
+			strconv.Itoa(s.Amplifier),
+			strconv.Itoa(r.Setting[s.Amplifier]),
+			strconv.Itoa(s.Value),
+			strconv.FormatInt(int64(s.Elapsed/time.Microsecond), 10)})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"2019/internal/intcode"
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Candidate is a phase setting and the signal it produces
+type Candidate struct {
+	Setting []int
+	Signal  int
+}
+
+// Search looks for the phase settings producing the highest signals. Every
+// amplifier gets a distinct phase from Min to Max.
+type Search struct {
+	Min        int
+	Max        int
+	Amplifiers int
+	// Workers bounds the number of settings evaluated at once; zero uses one
+	// worker per CPU
+	Workers int
+	// Top is the number of candidates returned
+	Top int
+}
+
+// Permute calls fn with every ordering of values using Heap's algorithm. The
+// slice passed to fn is reused between calls.
+func Permute(values []int, fn func(p []int)) {
+	p := append([]int{}, values...)
+	c := make([]int, len(p))
+
+	fn(p)
+	for i := 0; i < len(p); {
+		if c[i] >= i {
+			c[i] = 0
+			i++
+			continue
+		}
+
+		if i%2 == 0 {
+			p[0], p[i] = p[i], p[0]
+		} else {
+			p[c[i]], p[i] = p[i], p[c[i]]
+		}
+
+		fn(p)
+		c[i]++
+		i = 0
+	}
+}
+
+// combinations calls fn with every subset of size k of values, in order
+func combinations(values []int, k int, fn func(c []int)) {
+	c := make([]int, 0, k)
+
+	var pick func(start int)
+	pick = func(start int) {
+		if len(c) == k {
+			fn(c)
+			return
+		}
+
+		for i := start; i <= len(values)-(k-len(c)); i++ {
+			c = append(c, values[i])
+			pick(i + 1)
+			c = c[:len(c)-1]
+		}
+	}
+
+	pick(0)
+}
+
+// Settings calls fn with a copy of every valid phase setting
+func (s Search) Settings(fn func(setting []int)) {
+	phases := []int{}
+	for p := s.Min; p <= s.Max; p++ {
+		phases = append(phases, p)
+	}
+
+	if s.Amplifiers <= 0 || s.Amplifiers > len(phases) {
+		return
+	}
+
+	combinations(phases, s.Amplifiers, func(c []int) {
+		Permute(c, func(p []int) {
+			fn(append([]int{}, p...))
+		})
+	})
+}
+
+// Run evaluates every setting against the program and returns the best
+// candidates, highest signal first. Equal signals are ordered by setting so
+// the result does not depend on scheduling.
+func (s Search) Run(ctx context.Context, set []int) ([]Candidate, error) {
+	if s.Amplifiers <= 0 || s.Amplifiers > s.Max-s.Min+1 {
+		return nil, errors.New("amplifier count must be between one and the number of phases")
+	}
+
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	settings := make(chan []int)
+	results := make(chan Candidate)
+
+	var once sync.Once
+	var failure error
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for setting := range settings {
+				signal, _, err := intcode.NewRing(set, setting, 0).Run(ctx)
+				if err != nil {
+					once.Do(func() { failure = err })
+					cancel()
+					continue
+				}
+
+				results <- Candidate{Setting: setting, Signal: signal}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(settings)
+
+		s.Settings(func(setting []int) {
+			if ctx.Err() == nil {
+				settings <- setting
+			}
+		})
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	top := []Candidate{}
+	for c := range results {
+		top = insertCandidate(top, c, s.Top)
+	}
+
+	if failure != nil {
+		return nil, failure
+	}
+
+	return top, ctx.Err()
+}
+
+// insertCandidate adds c to the sorted slice if it is among the best k
+func insertCandidate(top []Candidate, c Candidate, k int) []Candidate {
+	i := sort.Search(len(top), func(i int) bool {
+		return better(c, top[i])
+	})
+
+	if k > 0 && i >= k {
+		return top
+	}
+
+	top = append(top, Candidate{})
+	copy(top[i+1:], top[i:])
+	top[i] = c
+
+	if k > 0 && len(top) > k {
+		top = top[:k]
+	}
+
+	return top
+}
+
+// better orders candidates by descending signal, then ascending setting
+func better(a, b Candidate) bool {
+	if a.Signal != b.Signal {
+		return a.Signal > b.Signal
+	}
+
+	for i := range a.Setting {
+		if i >= len(b.Setting) || a.Setting[i] != b.Setting[i] {
+			return i < len(b.Setting) && a.Setting[i] < b.Setting[i]
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"2019/internal/intcode"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s source.asm\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	codes, err := intcode.Assemble(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = intcode.WriteCodes(os.Stdout, codes)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
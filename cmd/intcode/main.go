@@ -0,0 +1,327 @@
+package main
+
+import (
+	"2019/internal/intcode"
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// exit codes reflecting how the program stopped
+const (
+	exitHalted     = 0
+	exitFailed     = 1
+	exitUsage      = 2
+	exitNeedsInput = 3
+)
+
+// Patch sets the value at an address before the program runs
+type Patch struct {
+	Address int
+	Value   int
+}
+
+// Patches collects repeated -set flags
+type Patches []Patch
+
+// String ...
+func (p *Patches) String() string {
+	values := make([]string, len(*p))
+	for i, patch := range *p {
+		values[i] = fmt.Sprintf("%v=%v", patch.Address, patch.Value)
+	}
+
+	return strings.Join(values, ",")
+}
+
+// Set parses address=value
+func (p *Patches) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid patch %q; expected address=value", s)
+	}
+
+	address, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || address < 0 {
+		return fmt.Errorf("invalid address in patch %q", s)
+	}
+
+	value, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return fmt.Errorf("invalid value in patch %q", s)
+	}
+
+	*p = append(*p, Patch{Address: address, Value: value})
+	return nil
+}
+
+// Apply writes the patches into the program, growing it when a patch is past
+// its end
+func (p Patches) Apply(codes []int) []int {
+	for _, patch := range p {
+		for patch.Address >= len(codes) {
+			codes = append(codes, 0)
+		}
+		codes[patch.Address] = patch.Value
+	}
+
+	return codes
+}
+
+// SliceReader returns the values in order followed by io.EOF
+type SliceReader struct {
+	values []int
+}
+
+// ReadValue ...
+func (r *SliceReader) ReadValue() (int, error) {
+	if len(r.values) == 0 {
+		return 0, io.EOF
+	}
+
+	v := r.values[0]
+	r.values = r.values[1:]
+	return v, nil
+}
+
+// CSVWriter writes values separated by commas
+type CSVWriter struct {
+	w       io.Writer
+	written bool
+}
+
+// WriteValue ...
+func (c *CSVWriter) WriteValue(v int) error {
+	sep := ","
+	if !c.written {
+		sep = ""
+		c.written = true
+	}
+
+	_, err := fmt.Fprintf(c.w, "%s%v", sep, v)
+	return err
+}
+
+// Close ends the line if any value was written
+func (c *CSVWriter) Close() error {
+	if !c.written {
+		return nil
+	}
+
+	_, err := fmt.Fprintln(c.w)
+	return err
+}
+
+// ChainReader reads from each reader in turn until it returns io.EOF
+type ChainReader []intcode.ValueReader
+
+// ReadValue ...
+func (c *ChainReader) ReadValue() (int, error) {
+	for len(*c) > 0 {
+		v, err := (*c)[0].ReadValue()
+		if err != io.EOF {
+			return v, err
+		}
+
+		*c = (*c)[1:]
+	}
+
+	return 0, io.EOF
+}
+
+// NewOutput returns the writer for an output format
+func NewOutput(format string, w io.Writer) (intcode.ValueWriter, error) {
+	switch format {
+	case "lines":
+		return intcode.NewTextWriter(w), nil
+	case "csv":
+		return &CSVWriter{w: w}, nil
+	case "ascii":
+		return intcode.NewASCIIWriter(w), nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q; expected lines, csv or ascii", format)
+}
+
+// run executes the command and returns the process exit code
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("intcode", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	program := flags.String("p", "", "program to run; - reads it from standard input, leaving no other input unless given with -i")
+	values := flags.String("i", "", "comma separated input values")
+	inputFile := flags.String("in", "", "file of comma or newline separated input values; - reads standard input")
+	inFormat := flags.String("f", "lines", "input format of -in and standard input: lines or ascii")
+	format := flags.String("o", "lines", "output format: lines, csv or ascii")
+	trace := flags.String("trace", "", "write a JSON lines trace of every instruction to this file")
+	patches := Patches{}
+	flags.Var(&patches, "set", "set address=value before running; may be repeated")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: intcode -p program.txt [flags]")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+
+	if *program == "" || flags.NArg() != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintf(stderr, "intcode: %s\n", err.Error())
+		return exitUsage
+	}
+
+	var codes []int
+	if *program == intcode.Stdin {
+		if *inputFile == intcode.Stdin {
+			return fail(errors.New("-p - and -in - cannot both read standard input"))
+		}
+
+		codes, err = intcode.Load(stdin)
+		// the program has used up standard input
+		stdin = strings.NewReader("")
+	} else {
+		codes, err = intcode.LoadFile(*program)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	output, err := NewOutput(*format, stdout)
+	if err != nil {
+		return fail(err)
+	}
+
+	comp := intcode.NewInstruction(0, patches.Apply(codes))
+	comp.Output = output
+	comp.Input, err = newInput(*values, *inputFile, *inFormat, stdin)
+	if err != nil {
+		return fail(err)
+	}
+
+	var tracer *intcode.TraceFile
+	if *trace != "" {
+		tracer, err = intcode.CreateTraceFile(*trace)
+		if err != nil {
+			return fail(err)
+		}
+		comp.Tracer = tracer
+	}
+
+	result := intcode.Run(comp)
+
+	if c, ok := output.(*CSVWriter); ok {
+		c.Close()
+	}
+
+	if tracer != nil {
+		err := tracer.Close()
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	switch result.Status {
+	case intcode.Halted:
+		return exitHalted
+	case intcode.NeedsInput:
+		fmt.Fprintf(stderr, "intcode: program needs more input after %v steps\n", result.Steps)
+		return exitNeedsInput
+	case intcode.Failed:
+		fmt.Fprintf(stderr, "intcode: %s\n", result.Fault.Error())
+		return exitFailed
+	}
+
+	fmt.Fprintf(stderr, "intcode: program stopped with status %v after %v steps\n", result.Status, result.Steps)
+	return exitFailed
+}
+
+// newInput reads values given with -i followed by those in the -in file.
+// Without either, values are read from stdin as they are needed. In the ascii
+// format every line of the file or stdin is read as character codes.
+func newInput(values string, path string, format string, stdin io.Reader) (intcode.ValueReader, error) {
+	if format != "lines" && format != "ascii" {
+		return nil, fmt.Errorf("unknown input format %q; expected lines or ascii", format)
+	}
+
+	input := ChainReader{}
+	if values != "" {
+		v, err := intcode.Load(strings.NewReader(values))
+		if err != nil {
+			return nil, fmt.Errorf("-i: %s", err.Error())
+		}
+		input = append(input, &SliceReader{values: v})
+	}
+
+	switch {
+	case path == "" && values != "":
+		return &input, nil
+	case path == "" && format == "ascii":
+		input = append(input, intcode.NewASCIIReader(stdin))
+	case path == "":
+		input = append(input, intcode.NewTextReader(stdin))
+	case format == "ascii":
+		var src io.Reader = stdin
+		if path != intcode.Stdin {
+			text, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			src = bytes.NewReader(text)
+		}
+		input = append(input, intcode.NewASCIIReader(src))
+	default:
+		var src io.Reader = stdin
+		if path != intcode.Stdin {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			src = file
+		}
+
+		v, err := loadValues(src)
+		if err != nil {
+			return nil, fmt.Errorf("-in: %s", err.Error())
+		}
+		input = append(input, &SliceReader{values: v})
+	}
+
+	return &input, nil
+}
+
+// loadValues reads each line as a list of comma separated values so that
+// values may also be given one per line
+func loadValues(r io.Reader) ([]int, error) {
+	values := []int{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		v, err := intcode.Load(strings.NewReader(scanner.Text()))
+		if le, ok := err.(*intcode.LoadError); ok {
+			le.Line = line
+			return nil, le
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v...)
+	}
+
+	return values, scanner.Err()
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
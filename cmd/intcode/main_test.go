@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func writeProgram(t *testing.T, src string) string {
+	file, err := os.CreateTemp("", "intcode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	file.WriteString(src)
+	return file.Name()
+}
+
+func TestRun(t *testing.T) {
+	// outputs the sum of two inputs followed by the value at address 16
+	path := writeProgram(t, "3,13,3,14,1,13,14,15,4,15,4,16,99,0,0,0,0\n")
+	defer os.Remove(path)
+
+	inputs := [][]string{
+		[]string{"-p", path, "-i", "2,3"},
+		[]string{"-p", path, "-i", "2,3", "-o", "csv", "-set", "16=7"},
+		[]string{"-p", path, "-i", "72", "-set", "2=4", "-set", "3=13", "-o", "ascii"},
+		[]string{"-p", path},
+		[]string{"-p", path, "-i", "2"},
+		[]string{"-p", path, "-i", "2,3", "-set", "0=55"},
+		[]string{"-p", path, "-o", "xml"},
+		[]string{}}
+	stdin := []string{"", "", "", "4\n5\n", "", "", "", ""}
+
+	expectedOut := []string{"5\n0\n", "5,7\n", "HH\x00", "9\n0\n", "", "", "", ""}
+	expectedCode := []int{exitHalted, exitHalted, exitHalted, exitHalted, exitNeedsInput, exitFailed, exitUsage, exitUsage}
+
+	for i, args := range inputs {
+		stdout := new(bytes.Buffer)
+		code := run(args, strings.NewReader(stdin[i]), stdout, new(bytes.Buffer))
+
+		if code != expectedCode[i] {
+			t.Errorf("incorrect exit code %v for test %v; expected %v", code, i+1, expectedCode[i])
+		}
+
+		if stdout.String() != expectedOut[i] {
+			t.Errorf("incorrect output %q for test %v; expected %q", stdout.String(), i+1, expectedOut[i])
+		}
+	}
+}
+
+func TestRun_Stdin(t *testing.T) {
+	path := writeProgram(t, "3,0,3,0,4,0,99")
+	defer os.Remove(path)
+
+	stdout := new(bytes.Buffer)
+	code := run([]string{"-p", path, "-i", "1", "-in", "-"}, strings.NewReader("8,\n"), stdout, new(bytes.Buffer))
+	if code != exitHalted || stdout.String() != "8\n" {
+		t.Errorf("incorrect output %q with exit code %v; expected %q", stdout.String(), code, "8\n")
+	}
+
+	stdout.Reset()
+	code = run([]string{"-p", path, "-in", "-"}, strings.NewReader("8\n9\n"), stdout, new(bytes.Buffer))
+	if code != exitHalted || stdout.String() != "9\n" {
+		t.Errorf("incorrect output %q with exit code %v; expected %q", stdout.String(), code, "9\n")
+	}
+
+	stderr := new(bytes.Buffer)
+	code = run([]string{"-p", path, "-in", "-"}, strings.NewReader("8\n9 10\n"), new(bytes.Buffer), stderr)
+	if code != exitUsage || !strings.Contains(stderr.String(), "line 2, column 3") {
+		t.Errorf("incorrect error %q with exit code %v; expected the position of the missing comma", stderr.String(), code)
+	}
+
+	stdout.Reset()
+	code = run([]string{"-p", "-"}, strings.NewReader("104,42,99"), stdout, new(bytes.Buffer))
+	if code != exitHalted || stdout.String() != "42\n" {
+		t.Errorf("incorrect output %q with exit code %v; expected %q", stdout.String(), code, "42\n")
+	}
+
+	// the program read from standard input leaves nothing for input values
+	code = run([]string{"-p", "-"}, strings.NewReader("3,0,99\n"), new(bytes.Buffer), new(bytes.Buffer))
+	if code != exitNeedsInput {
+		t.Errorf("incorrect exit code %v; expected %v", code, exitNeedsInput)
+	}
+
+	code = run([]string{"-p", "-", "-in", "-"}, strings.NewReader("104,42,99"), new(bytes.Buffer), new(bytes.Buffer))
+	if code != exitUsage {
+		t.Errorf("incorrect exit code %v; expected %v", code, exitUsage)
+	}
+}
+
+func TestRun_StdinWithoutNewline(t *testing.T) {
+	path := writeProgram(t, "3,0,4,0,99")
+	defer os.Remove(path)
+
+	stdout := new(bytes.Buffer)
+	code := run([]string{"-p", path}, strings.NewReader("5"), stdout, new(bytes.Buffer))
+	if code != exitHalted || stdout.String() != "5\n" {
+		t.Errorf("incorrect output %q with exit code %v; expected %q", stdout.String(), code, "5\n")
+	}
+}
+
+func TestPatches_Set(t *testing.T) {
+	inputs := []string{"1=12", " 2 = -3 ", "x=1", "1", "-1=2", "1=y"}
+	valid := []bool{true, true, false, false, false, false}
+
+	for i, input := range inputs {
+		p := Patches{}
+		err := p.Set(input)
+		if (err == nil) != valid[i] {
+			t.Errorf("incorrect result %v for %q; expected valid %v", err, input, valid[i])
+		}
+	}
+}
+
+func TestRun_ASCII(t *testing.T) {
+	// echoes one line of input
+	path := writeProgram(t, "3,100,4,100,1008,100,10,101,1006,101,0,99")
+	defer os.Remove(path)
+
+	stdout := new(bytes.Buffer)
+	code := run([]string{"-p", path, "-f", "ascii", "-o", "ascii"}, strings.NewReader("walk"), stdout, new(bytes.Buffer))
+	if code != exitHalted || stdout.String() != "walk\n" {
+		t.Errorf("incorrect output %q with exit code %v; expected %q", stdout.String(), code, "walk\n")
+	}
+
+	code = run([]string{"-p", path, "-f", "morse"}, strings.NewReader(""), new(bytes.Buffer), new(bytes.Buffer))
+	if code != exitUsage {
+		t.Errorf("incorrect exit code %v; expected %v", code, exitUsage)
+	}
+}
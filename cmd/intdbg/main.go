@@ -0,0 +1,423 @@
+package main
+
+import (
+	"2019/internal/intcode"
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxDump is the most memory values shown by a single m command
+const maxDump = 256
+
+const help = `commands:
+  s [n]          execute n instructions (default 1)
+  c              continue until a breakpoint, watchpoint, input request or halt
+  b <addr|op>    break before the instruction at an address or with a mnemonic
+  d <addr|op>    delete a breakpoint
+  w <addr>       stop when the value at an address changes
+  u <addr>       remove a watchpoint
+  l              list breakpoints and watchpoints
+  r              show registers and the next instruction
+  m <addr> [n]   show n memory values starting at an address (default 8, at most 256)
+  i <v>[,<v>]    queue input values
+  q              quit`
+
+// Debugger ...
+type Debugger struct {
+	inst        *intcode.Instruction
+	input       []int
+	breakpoints map[int]bool
+	opBreaks    map[intcode.OpCode]bool
+	watches     map[int]int
+	halted      bool
+	fault       error
+	out         io.Writer
+}
+
+// NewDebugger loads a copy of the program and decodes its first instruction
+func NewDebugger(set []int, out io.Writer) *Debugger {
+	data := make([]int, len(set))
+	copy(data, set)
+
+	d := &Debugger{
+		inst:        intcode.NewInstruction(0, data),
+		input:       []int{},
+		breakpoints: make(map[int]bool),
+		opBreaks:    make(map[intcode.OpCode]bool),
+		watches:     make(map[int]int),
+		out:         out}
+
+	d.inst.Input = d
+	d.inst.Output = d
+	d.decode()
+
+	return d
+}
+
+// ReadValue supplies queued input to the program
+func (d *Debugger) ReadValue() (int, error) {
+	if len(d.input) == 0 {
+		return 0, io.EOF
+	}
+
+	v := d.input[0]
+	d.input = d.input[1:]
+	return v, nil
+}
+
+// WriteValue prints program output
+func (d *Debugger) WriteValue(value int) error {
+	fmt.Fprintf(d.out, "output: %v\n", value)
+	return nil
+}
+
+func (d *Debugger) decode() {
+	err := d.inst.Step()
+	d.stop(err)
+}
+
+// stop records whether err ended the program
+func (d *Debugger) stop(err error) {
+	if err == nil {
+		return
+	}
+
+	if e, ok := err.(*intcode.CodeTerminationError); ok && e.ExitCode() == 0 {
+		d.halted = true
+		return
+	}
+
+	d.fault = err
+}
+
+func (d *Debugger) stopped() string {
+	if d.halted {
+		return "program halted"
+	}
+
+	if d.fault != nil {
+		return fmt.Sprintf("program failed: %s", d.fault.Error())
+	}
+
+	return ""
+}
+
+// step executes the decoded instruction and decodes the next one. It returns
+// a non-empty reason when execution should not continue.
+func (d *Debugger) step() string {
+	if reason := d.stopped(); reason != "" {
+		return reason
+	}
+
+	if d.inst.Op == intcode.InputOp && len(d.input) == 0 {
+		return "waiting for input"
+	}
+
+	err := d.inst.Exec()
+	if err != nil {
+		d.stop(err)
+		return d.stopped()
+	}
+
+	reason := d.checkWatches()
+	d.decode()
+	if reason == "" {
+		reason = d.stopped()
+	}
+
+	return reason
+}
+
+func (d *Debugger) checkWatches() string {
+	changed := []string{}
+	for _, address := range d.sortedWatches() {
+		v, _ := d.inst.Peek(address)
+		if v != d.watches[address] {
+			changed = append(changed, fmt.Sprintf("watch %v: %v -> %v", address, d.watches[address], v))
+			d.watches[address] = v
+		}
+	}
+
+	return strings.Join(changed, "; ")
+}
+
+func (d *Debugger) atBreakpoint() bool {
+	return d.breakpoints[d.inst.Position] || d.opBreaks[d.inst.Op]
+}
+
+// Continue steps until something stops execution
+func (d *Debugger) Continue() string {
+	for {
+		reason := d.step()
+		if reason != "" {
+			return reason
+		}
+
+		if d.atBreakpoint() {
+			return fmt.Sprintf("breakpoint at %v", d.inst.Position)
+		}
+	}
+}
+
+// Registers ...
+func (d *Debugger) Registers() string {
+	// an instruction is an opcode followed by at most four parameters, read
+	// through Peek so that paged memory is included
+	values := []int{}
+	for a := d.inst.Position; a <= d.inst.Position+4; a++ {
+		v, err := d.inst.Peek(a)
+		if err != nil {
+			break
+		}
+		values = append(values, v)
+	}
+
+	next := "?"
+	line, ok := intcode.DisassembleAt(0, values)
+	if ok {
+		line.Address = d.inst.Position
+		next = strings.TrimSpace(line.String())
+	}
+
+	return fmt.Sprintf("ip=%v rb=%v next: %s", d.inst.Position, d.inst.RelPos, next)
+}
+
+func (d *Debugger) sortedWatches() []int {
+	addresses := []int{}
+	for a := range d.watches {
+		addresses = append(addresses, a)
+	}
+	sort.Ints(addresses)
+
+	return addresses
+}
+
+func parseBreakpoint(arg string) (int, intcode.OpCode, bool, error) {
+	if code, ok := intcode.ParseMnemonic(arg); ok {
+		return 0, code, true, nil
+	}
+
+	address, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("invalid address or mnemonic %s", arg)
+	}
+
+	return address, 0, false, nil
+}
+
+func parseValues(args []string) ([]int, error) {
+	values := []int{}
+	for _, a := range strings.Split(strings.Join(args, ","), ",") {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %s", a)
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// Execute runs a single debugger command and reports whether the session
+// should end
+func (d *Debugger) Execute(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+
+	cmd, args := fields[0], fields[1:]
+	var err error
+
+	switch cmd {
+	case "s", "step":
+		n := 1
+		if len(args) > 0 {
+			n, err = strconv.Atoi(args[0])
+			if err != nil {
+				break
+			}
+		}
+
+		for i := 0; i < n; i++ {
+			if reason := d.step(); reason != "" {
+				fmt.Fprintln(d.out, reason)
+				break
+			}
+		}
+		fmt.Fprintln(d.out, d.Registers())
+	case "c", "continue":
+		fmt.Fprintln(d.out, d.Continue())
+		fmt.Fprintln(d.out, d.Registers())
+	case "b", "break", "d", "delete":
+		if len(args) != 1 {
+			err = fmt.Errorf("%s requires an address or mnemonic", cmd)
+			break
+		}
+
+		var address int
+		var code intcode.OpCode
+		var isOp bool
+		address, code, isOp, err = parseBreakpoint(args[0])
+		if err != nil {
+			break
+		}
+
+		set := cmd == "b" || cmd == "break"
+		switch {
+		case isOp && set:
+			d.opBreaks[code] = true
+		case isOp:
+			delete(d.opBreaks, code)
+		case set:
+			d.breakpoints[address] = true
+		default:
+			delete(d.breakpoints, address)
+		}
+	case "w", "watch", "u", "unwatch":
+		if len(args) != 1 {
+			err = fmt.Errorf("%s requires an address", cmd)
+			break
+		}
+
+		var address int
+		address, err = strconv.Atoi(args[0])
+		if err != nil {
+			break
+		}
+
+		if cmd == "u" || cmd == "unwatch" {
+			delete(d.watches, address)
+			break
+		}
+
+		var v int
+		v, err = d.inst.Peek(address)
+		if err == nil {
+			d.watches[address] = v
+		}
+	case "l", "list":
+		addresses := []int{}
+		for a := range d.breakpoints {
+			addresses = append(addresses, a)
+		}
+		sort.Ints(addresses)
+		for _, a := range addresses {
+			fmt.Fprintf(d.out, "break %v\n", a)
+		}
+
+		ops := []string{}
+		for code := range d.opBreaks {
+			m, _ := intcode.Mnemonic(code)
+			ops = append(ops, m)
+		}
+		sort.Strings(ops)
+		for _, m := range ops {
+			fmt.Fprintf(d.out, "break %s\n", m)
+		}
+
+		for _, a := range d.sortedWatches() {
+			fmt.Fprintf(d.out, "watch %v = %v\n", a, d.watches[a])
+		}
+	case "r", "regs":
+		fmt.Fprintln(d.out, d.Registers())
+	case "m", "mem":
+		if len(args) == 0 {
+			err = fmt.Errorf("%s requires an address", cmd)
+			break
+		}
+
+		var address int
+		address, err = strconv.Atoi(args[0])
+		if err != nil {
+			break
+		}
+
+		n := 8
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil {
+				break
+			}
+		}
+
+		if n < 1 || n > maxDump {
+			err = fmt.Errorf("count must be between 1 and %v", maxDump)
+			break
+		}
+
+		values := []string{}
+		for a := address; a < address+n && err == nil; a++ {
+			var v int
+			v, err = d.inst.Peek(a)
+			values = append(values, strconv.Itoa(v))
+		}
+		if err != nil {
+			break
+		}
+		fmt.Fprintf(d.out, "%v: %s\n", address, strings.Join(values, " "))
+	case "i", "input":
+		var values []int
+		values, err = parseValues(args)
+		if err == nil {
+			d.input = append(d.input, values...)
+		}
+	case "h", "help":
+		fmt.Fprintln(d.out, help)
+	case "q", "quit":
+		return true
+	default:
+		err = fmt.Errorf("unknown command %s; type h for help", cmd)
+	}
+
+	if err != nil {
+		fmt.Fprintf(d.out, "error: %s\n", err.Error())
+	}
+
+	return false
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s program.txt\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	codes, err := intcode.LoadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d := NewDebugger(codes, os.Stdout)
+	fmt.Println(d.Registers())
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("(intdbg) ")
+		if !scanner.Scan() {
+			return
+		}
+
+		if d.Execute(scanner.Text()) {
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"2019/internal/intcode"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDebugger_Step(t *testing.T) {
+	codes := []int{1101, 2, 3, 9, 4, 9, 99, 0, 0, 0}
+	buf := new(bytes.Buffer)
+	d := NewDebugger(codes, buf)
+
+	d.Execute("s")
+	if d.inst.Position != 4 {
+		t.Errorf("incorrect position %v; expected %v", d.inst.Position, 4)
+	}
+
+	d.Execute("s 5")
+	if !d.halted {
+		t.Errorf("expected program to halt")
+	}
+
+	if !strings.Contains(buf.String(), "output: 5\n") {
+		t.Errorf("missing output in %q", buf.String())
+	}
+
+	if codes[9] != 0 {
+		t.Errorf("program was modified; expected the debugger to use a copy")
+	}
+}
+
+func TestDebugger_Breakpoints(t *testing.T) {
+	codes := []int{1101, 2, 3, 9, 4, 9, 99, 0, 0, 0}
+	inputs := []string{"b 4", "b out", "b 6", "b hlt"}
+	expected := []int{4, 4, 6, 6}
+
+	for i, input := range inputs {
+		d := NewDebugger(codes, new(bytes.Buffer))
+		d.Execute(input)
+		d.Execute("c")
+
+		if d.inst.Position != expected[i] {
+			t.Errorf("incorrect position %v for %q; expected %v", d.inst.Position, input, expected[i])
+		}
+	}
+}
+
+func TestDebugger_Watch(t *testing.T) {
+	codes := []int{1101, 2, 3, 9, 1101, 2, 3, 10, 99, 0, 0}
+	buf := new(bytes.Buffer)
+	d := NewDebugger(codes, buf)
+
+	d.Execute("w 10")
+	d.Execute("c")
+
+	if d.inst.Position != 8 {
+		t.Errorf("incorrect position %v; expected %v", d.inst.Position, 8)
+	}
+
+	if !strings.Contains(buf.String(), "watch 10: 0 -> 5") {
+		t.Errorf("missing watch report in %q", buf.String())
+	}
+}
+
+func TestDebugger_Input(t *testing.T) {
+	codes := []int{3, 0, 4, 0, 99}
+	buf := new(bytes.Buffer)
+	d := NewDebugger(codes, buf)
+
+	d.Execute("c")
+	if !strings.Contains(buf.String(), "waiting for input") {
+		t.Errorf("missing input request in %q", buf.String())
+	}
+
+	d.Execute("i 42")
+	d.Execute("c")
+	if !strings.Contains(buf.String(), "output: 42\n") {
+		t.Errorf("missing output in %q", buf.String())
+	}
+
+	if !d.halted {
+		t.Errorf("expected program to halt")
+	}
+}
+
+func TestDebugger_Memory(t *testing.T) {
+	codes := []int{109, 7, 99}
+	buf := new(bytes.Buffer)
+	d := NewDebugger(codes, buf)
+
+	d.Execute("s")
+	d.Execute("r")
+	d.Execute("m 0 4")
+	d.Execute("m 0 1000000000")
+
+	expected := []string{"ip=2 rb=7 next: 2  hlt", "0: 109 7 99 0", "error: count must be between 1 and 256"}
+	for _, e := range expected {
+		if !strings.Contains(buf.String(), e) {
+			t.Errorf("missing %q in %q", e, buf.String())
+		}
+	}
+}
+
+func TestDebugger_PagedMemory(t *testing.T) {
+	// stores hlt past the end of the program and jumps to it
+	codes := []int{1101, 0, 99, 10, 1105, 1, 10}
+	buf := new(bytes.Buffer)
+	d := NewDebugger(codes, buf)
+	d.inst.Memory = intcode.NewMemory(11)
+
+	d.Execute("s")
+	d.Execute("s")
+	d.Execute("r")
+	d.Execute("m 9 4")
+
+	if !strings.Contains(buf.String(), "ip=10 rb=0 next: 10  hlt") {
+		t.Errorf("missing the instruction in paged memory in %q", buf.String())
+	}
+
+	if strings.Contains(buf.String(), "9: ") || !strings.Contains(buf.String(), "error: ") {
+		t.Errorf("incorrect output %q; expected only an error for a range past the memory limit", buf.String())
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"2019/internal/intcode"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s program.txt|-\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	codes, err := intcode.LoadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = intcode.WriteDisassembly(os.Stdout, codes)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,108 @@
+package intcode
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Arithmetic selects how a machine handles results that do not fit in an int
+type Arithmetic int
+
+const (
+	// NativeArithmetic wraps around on overflow like Go integers
+	NativeArithmetic Arithmetic = 0
+	// CheckedArithmetic fails the program when a result or a relative
+	// address overflows
+	CheckedArithmetic Arithmetic = 1
+)
+
+const (
+	maxInt = 1<<(strconv.IntSize-1) - 1
+	minInt = -1 << (strconv.IntSize - 1)
+)
+
+// OverflowError ...
+type OverflowError struct {
+	Op OpCode
+	A  int
+	B  int
+}
+
+// Error ...
+func (e *OverflowError) Error() string {
+	m, _ := Mnemonic(e.Op)
+	return fmt.Sprintf("integer overflow in %s %v, %v", m, e.A, e.B)
+}
+
+// CheckedAdd returns false when the sum overflows
+func CheckedAdd(i, j int) (int, bool) {
+	if (j > 0 && i > maxInt-j) || (j < 0 && i < minInt-j) {
+		return 0, false
+	}
+
+	return i + j, true
+}
+
+// CheckedMult returns false when the product overflows
+func CheckedMult(i, j int) (int, bool) {
+	if i == 0 || j == 0 {
+		return 0, true
+	}
+
+	if (i == -1 && j == minInt) || (j == -1 && i == minInt) {
+		return 0, false
+	}
+
+	r := i * j
+	if r/j != i {
+		return 0, false
+	}
+
+	return r, true
+}
+
+// SetArithmetic selects how the machine handles results that do not fit in
+// an int. Programs needing values beyond an int can run on a BigMachine.
+func (m *Machine) SetArithmetic(a Arithmetic) error {
+	err := a.validate()
+	if err != nil {
+		return err
+	}
+
+	m.inst.Arithmetic = a
+	return nil
+}
+
+func (a Arithmetic) validate() error {
+	if a != NativeArithmetic && a != CheckedArithmetic {
+		return fmt.Errorf("unknown arithmetic %v", int(a))
+	}
+
+	return nil
+}
+
+func (i *Instruction) add(a, b int) (int, error) {
+	if i.Arithmetic != CheckedArithmetic {
+		return Add(a, b), nil
+	}
+
+	r, ok := CheckedAdd(a, b)
+	if !ok {
+		return 0, &OverflowError{Op: i.Op, A: a, B: b}
+	}
+
+	return r, nil
+}
+
+func (i *Instruction) mult(a, b int) (int, error) {
+	if i.Arithmetic != CheckedArithmetic {
+		return Mult(a, b), nil
+	}
+
+	r, ok := CheckedMult(a, b)
+	if !ok {
+		return 0, &OverflowError{Op: i.Op, A: a, B: b}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,100 @@
+package intcode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckedAdd(t *testing.T) {
+	inputs := [][]int{
+		[]int{1, 2},
+		[]int{maxInt, 1},
+		[]int{minInt, -1},
+		[]int{maxInt, minInt},
+		[]int{-5, 3}}
+	expected := []bool{true, false, false, true, true}
+
+	for i, input := range inputs {
+		r, ok := CheckedAdd(input[0], input[1])
+		if ok != expected[i] {
+			t.Errorf("incorrect overflow result %v for %v; expected %v", ok, input, expected[i])
+		}
+
+		if ok && r != input[0]+input[1] {
+			t.Errorf("incorrect sum %v for %v; expected %v", r, input, input[0]+input[1])
+		}
+	}
+}
+
+func TestCheckedMult(t *testing.T) {
+	inputs := [][]int{
+		[]int{34915192, 34915192},
+		[]int{0, maxInt},
+		[]int{-1, minInt},
+		[]int{minInt, -1},
+		[]int{1 << 32, 1 << 31},
+		[]int{1 << 32, 1 << 30},
+		[]int{34463338, 34463338 * 34463338}}
+	expected := []bool{true, true, false, false, false, true, false}
+
+	for i, input := range inputs {
+		r, ok := CheckedMult(input[0], input[1])
+		if ok != expected[i] {
+			t.Errorf("incorrect overflow result %v for %v; expected %v", ok, input, expected[i])
+		}
+
+		if ok && r != input[0]*input[1] {
+			t.Errorf("incorrect product %v for %v; expected %v", r, input, input[0]*input[1])
+		}
+	}
+}
+
+func TestMachine_CheckedArithmetic(t *testing.T) {
+	codes := []int{1102, 4611686018427387904, 4, 9, 4, 9, 99}
+	arithmetic := []Arithmetic{NativeArithmetic, CheckedArithmetic}
+	expected := []Status{HasOutput, Failed}
+
+	for i, a := range arithmetic {
+		m := NewMachine(codes)
+		err := m.SetArithmetic(a)
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			continue
+		}
+
+		status, err := m.Run()
+		if status != expected[i] {
+			t.Errorf("incorrect status %v for arithmetic %v; expected %v", status, a, expected[i])
+		}
+
+		if status != Failed {
+			continue
+		}
+
+		f, ok := err.(*Fault)
+		if !ok || f.Position != 0 || f.Op != 1102 {
+			t.Errorf("incorrect fault %v; expected overflow at position 0", err)
+		}
+	}
+
+	if NewMachine(codes).SetArithmetic(Arithmetic(2)) == nil {
+		t.Errorf("expected error selecting unknown arithmetic")
+	}
+}
+
+func TestMachine_CheckedRelativeAddress(t *testing.T) {
+	// moves the relative base to the largest int and reads one past it
+	codes := []int{109, maxInt, 204, 1, 99}
+	arithmetic := []Arithmetic{NativeArithmetic, CheckedArithmetic}
+	expected := []string{"address", "overflow"}
+
+	for i, a := range arithmetic {
+		m := NewMachine(codes)
+		m.SetArithmetic(a)
+
+		status, err := m.Run()
+		if status != Failed || err == nil || !strings.Contains(err.Error(), expected[i]) {
+			t.Errorf("incorrect result %v, %v for arithmetic %v; expected a fault mentioning %s", status, err, a, expected[i])
+		}
+	}
+}
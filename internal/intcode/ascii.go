@@ -0,0 +1,91 @@
+package intcode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// asciiLimit is the first value that is not an ASCII character
+const asciiLimit = 128
+
+// EncodeASCII returns the character codes of a line of input, ending with a
+// newline as ASCII programs expect
+func EncodeASCII(line string) []int {
+	line = strings.TrimRight(line, "\r\n") + "\n"
+	values := make([]int, len(line))
+	for i := 0; i < len(line); i++ {
+		values[i] = int(line[i])
+	}
+
+	return values
+}
+
+// DecodeASCII renders values the way ASCIIWriter does
+func DecodeASCII(values []int) string {
+	var b strings.Builder
+	w := NewASCIIWriter(&b)
+	for _, v := range values {
+		w.WriteValue(v)
+	}
+
+	return b.String()
+}
+
+// ASCIIReader supplies text a line at a time as character codes. A final line
+// without a newline is given one.
+type ASCIIReader struct {
+	reader  *bufio.Reader
+	pending []int
+}
+
+// NewASCIIReader ...
+func NewASCIIReader(r io.Reader) *ASCIIReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &ASCIIReader{reader: br}
+}
+
+// ReadValue returns the next character, reading another line when the
+// current one is used up
+func (r *ASCIIReader) ReadValue() (int, error) {
+	if len(r.pending) == 0 {
+		line, err := r.reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return 0, err
+		}
+
+		r.pending = EncodeASCII(line)
+	}
+
+	v := r.pending[0]
+	r.pending = r.pending[1:]
+	return v, nil
+}
+
+// ASCIIWriter writes values below 128 as characters. Other values, such as a
+// final answer, are written as decimal numbers on their own line.
+type ASCIIWriter struct {
+	writer io.Writer
+}
+
+// NewASCIIWriter ...
+func NewASCIIWriter(w io.Writer) *ASCIIWriter {
+	return &ASCIIWriter{writer: w}
+}
+
+// WriteValue ...
+func (w *ASCIIWriter) WriteValue(value int) error {
+	var err error
+	if value >= 0 && value < asciiLimit {
+		_, err = w.writer.Write([]byte{byte(value)})
+	} else {
+		_, err = fmt.Fprintf(w.writer, "%v\n", value)
+	}
+
+	return err
+}
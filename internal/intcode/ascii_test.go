@@ -0,0 +1,95 @@
+package intcode
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// echoLine outputs every character it reads up to and including a newline
+var echoLine = []int{3, 100, 4, 100, 1008, 100, 10, 101, 1006, 101, 0, 99}
+
+func TestEncodeASCII(t *testing.T) {
+	inputs := []string{"NOT A J", "WALK\n", "a\r\n", ""}
+	expected := [][]int{
+		[]int{78, 79, 84, 32, 65, 32, 74, 10},
+		[]int{87, 65, 76, 75, 10},
+		[]int{97, 10},
+		[]int{10}}
+
+	for i, input := range inputs {
+		values := EncodeASCII(input)
+		if !equalCodes(values, expected[i]) {
+			t.Errorf("incorrect values %v for %q; expected %v", values, input, expected[i])
+		}
+	}
+}
+
+func TestDecodeASCII(t *testing.T) {
+	inputs := [][]int{
+		[]int{35, 46, 10, 46, 35},
+		[]int{68, 111, 110, 101, 10, 19348359},
+		[]int{-1}}
+	expected := []string{"#.\n.#", "Done\n19348359\n", "-1\n"}
+
+	for i, input := range inputs {
+		text := DecodeASCII(input)
+		if text != expected[i] {
+			t.Errorf("incorrect text %q for test %v; expected %q", text, i+1, expected[i])
+		}
+	}
+}
+
+func TestASCIIReader(t *testing.T) {
+	r := NewASCIIReader(strings.NewReader("hi\nyo"))
+	expected := EncodeASCII("hi")
+	expected = append(expected, EncodeASCII("yo")...)
+
+	for i, e := range expected {
+		v, err := r.ReadValue()
+		if err != nil || v != e {
+			t.Errorf("incorrect value %v for character %v; expected %v", v, i, e)
+		}
+	}
+
+	if _, err := r.ReadValue(); err != io.EOF {
+		t.Errorf("incorrect error %v; expected %v", err, io.EOF)
+	}
+}
+
+func TestASCII_Process(t *testing.T) {
+	comp := NewInstruction(0, append([]int{}, echoLine...))
+	out := new(bytes.Buffer)
+	comp.Input = NewASCIIReader(strings.NewReader("hello\nignored\n"))
+	comp.Output = NewASCIIWriter(out)
+
+	result := Run(comp)
+	if result.Status != Halted {
+		t.Errorf("incorrect status %v; expected %v", result.Status, Halted)
+	}
+
+	if out.String() != "hello\n" {
+		t.Errorf("incorrect output %q; expected %q", out.String(), "hello\n")
+	}
+}
+
+func TestASCII_Machine(t *testing.T) {
+	m := NewMachine(echoLine)
+	m.Feed(EncodeASCII("ok")...)
+
+	values := []int{}
+	for {
+		status, err := m.Run()
+		if err != nil || status != HasOutput {
+			break
+		}
+
+		v, _ := m.Output()
+		values = append(values, v)
+	}
+
+	if DecodeASCII(values) != "ok\n" {
+		t.Errorf("incorrect output %q; expected %q", DecodeASCII(values), "ok\n")
+	}
+}
@@ -0,0 +1,392 @@
+package intcode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxMacroDepth guards against macros that expand themselves
+const maxMacroDepth = 16
+
+var (
+	labelPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.]*):`)
+	namePattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+)
+
+// AsmError ...
+type AsmError struct {
+	Line    int
+	Message string
+}
+
+// Error ...
+func (e *AsmError) Error() string {
+	return fmt.Sprintf("line %v: %s", e.Line, e.Message)
+}
+
+// statement is a single instruction or directive after macro expansion
+type statement struct {
+	line     int
+	name     string
+	operands []string
+}
+
+type macro struct {
+	params []string
+	// order lists params longest first so \ab is never replaced as \a
+	order []int
+	body  []sourceLine
+}
+
+type sourceLine struct {
+	number int
+	text   string
+}
+
+type assembler struct {
+	ops        *OpTable
+	macros     map[string]*macro
+	labels     map[string]int
+	statements []statement
+	address    int
+	expansions int
+}
+
+// Assemble translates assembly source into a program using DefaultOps
+func Assemble(r io.Reader) ([]int, error) {
+	return DefaultOps.Assemble(r)
+}
+
+// Assemble translates assembly source into a program.
+//
+// Each line holds an optional label (name:), followed by an instruction or a
+// directive; comments start with a semicolon. Instructions use the mnemonics
+// produced by Disassemble and take comma separated operands:
+//
+//	#5          immediate value
+//	@label      immediate address of a label, optionally with an offset (@label+2)
+//	[12]        position mode, also [@label]
+//	[rb+3]      relative mode, also [rb] and [rb-3]
+//
+// A plain number is rejected rather than guessing its mode.
+//
+// The .data directive emits its comma separated values as is. Macros are
+// defined between .macro name arg1, arg2 and .endm, and are invoked by name;
+// \arg1 in the body is replaced by the argument and \@ by a number unique to
+// each expansion so macros can declare their own labels.
+func (t *OpTable) Assemble(r io.Reader) ([]int, error) {
+	asm := &assembler{
+		ops:    t,
+		macros: make(map[string]*macro),
+		labels: make(map[string]int)}
+
+	lines := []sourceLine{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		lines = append(lines, sourceLine{number: n, text: scanner.Text()})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	err := asm.parse(lines, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return asm.encode()
+}
+
+// AssembleString ...
+func AssembleString(src string) ([]int, error) {
+	return Assemble(strings.NewReader(src))
+}
+
+func (a *assembler) parse(lines []sourceLine, depth int) error {
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		text := stripComment(line.text)
+
+		for {
+			m := labelPattern.FindStringSubmatch(text)
+			if m == nil {
+				break
+			}
+
+			if _, ok := a.labels[m[1]]; ok {
+				return &AsmError{Line: line.number, Message: fmt.Sprintf("duplicate label %s", m[1])}
+			}
+
+			a.labels[m[1]] = a.address
+			text = strings.TrimSpace(text[len(m[0]):])
+		}
+
+		if text == "" {
+			continue
+		}
+
+		name, operands := splitStatement(text)
+		switch name {
+		case ".macro":
+			end, err := a.define(lines, i, operands)
+			if err != nil {
+				return err
+			}
+
+			i = end
+		case ".endm":
+			return &AsmError{Line: line.number, Message: ".endm without .macro"}
+		case DataDirective:
+			if len(operands) == 0 {
+				return &AsmError{Line: line.number, Message: ".data requires at least one value"}
+			}
+
+			a.add(statement{line: line.number, name: name, operands: operands}, len(operands))
+		default:
+			if m, ok := a.macros[name]; ok {
+				err := a.expand(m, line.number, operands, depth)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			code, ok := a.ops.ParseMnemonic(name)
+			if !ok {
+				return &AsmError{Line: line.number, Message: fmt.Sprintf("unknown instruction %s", name)}
+			}
+
+			quantity, _ := a.ops.Arity(code)
+			if len(operands) != quantity {
+				m := fmt.Sprintf("%s takes %v operands; got %v", name, quantity, len(operands))
+				return &AsmError{Line: line.number, Message: m}
+			}
+
+			a.add(statement{line: line.number, name: name, operands: operands}, quantity+1)
+		}
+	}
+
+	return nil
+}
+
+func (a *assembler) add(s statement, size int) {
+	a.statements = append(a.statements, s)
+	a.address += size
+}
+
+// define records the macro starting at lines[start] and returns the index of
+// its .endm line
+func (a *assembler) define(lines []sourceLine, start int, operands []string) (int, error) {
+	number := lines[start].number
+	if len(operands) == 0 {
+		return 0, &AsmError{Line: number, Message: ".macro requires a name"}
+	}
+
+	fields := strings.Fields(operands[0])
+	if len(fields) == 0 || !namePattern.MatchString(fields[0]) {
+		return 0, &AsmError{Line: number, Message: "invalid macro name"}
+	}
+
+	name := fields[0]
+	if _, ok := a.ops.ParseMnemonic(name); ok || strings.HasPrefix(name, ".") {
+		return 0, &AsmError{Line: number, Message: fmt.Sprintf("macro %s shadows an instruction", name)}
+	}
+
+	params := []string{}
+	if len(fields) > 1 {
+		params = append(params, strings.Join(fields[1:], " "))
+	}
+	params = append(params, operands[1:]...)
+
+	m := &macro{params: params, order: make([]int, len(params))}
+	for i := range m.order {
+		m.order[i] = i
+	}
+	sort.Slice(m.order, func(i, j int) bool {
+		return len(params[m.order[i]]) > len(params[m.order[j]])
+	})
+
+	for i := start + 1; i < len(lines); i++ {
+		directive, _ := splitStatement(stripComment(lines[i].text))
+		if directive == ".endm" {
+			a.macros[name] = m
+			return i, nil
+		}
+
+		m.body = append(m.body, lines[i])
+	}
+
+	return 0, &AsmError{Line: number, Message: fmt.Sprintf("macro %s is missing .endm", name)}
+}
+
+func (a *assembler) expand(m *macro, number int, args []string, depth int) error {
+	if depth >= maxMacroDepth {
+		return &AsmError{Line: number, Message: "macro expansion too deep"}
+	}
+
+	if len(args) != len(m.params) {
+		msg := fmt.Sprintf("macro takes %v arguments; got %v", len(m.params), len(args))
+		return &AsmError{Line: number, Message: msg}
+	}
+
+	a.expansions++
+	pairs := []string{`\@`, strconv.Itoa(a.expansions)}
+	for _, i := range m.order {
+		pairs = append(pairs, `\`+m.params[i], args[i])
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	body := make([]sourceLine, len(m.body))
+	for i, line := range m.body {
+		// errors inside an expansion are reported at the invocation
+		body[i] = sourceLine{number: number, text: replacer.Replace(line.text)}
+	}
+
+	return a.parse(body, depth+1)
+}
+
+func (a *assembler) encode() ([]int, error) {
+	codes := make([]int, 0, a.address)
+
+	for _, s := range a.statements {
+		if s.name == DataDirective {
+			for _, o := range s.operands {
+				v, err := a.evaluate(o)
+				if err != nil {
+					return nil, &AsmError{Line: s.line, Message: err.Error()}
+				}
+				codes = append(codes, v)
+			}
+			continue
+		}
+
+		code, _ := a.ops.ParseMnemonic(s.name)
+		op := int(code)
+		values := make([]int, len(s.operands))
+		scale := 100
+		for i, o := range s.operands {
+			v, mode, err := a.operand(o)
+			if err != nil {
+				return nil, &AsmError{Line: s.line, Message: err.Error()}
+			}
+
+			op += int(mode) * scale
+			scale *= 10
+			values[i] = v
+		}
+
+		codes = append(codes, op)
+		codes = append(codes, values...)
+	}
+
+	return codes, nil
+}
+
+func (a *assembler) operand(text string) (int, ParameterMode, error) {
+	switch {
+	case strings.HasPrefix(text, "#"):
+		v, err := a.evaluate(strings.TrimSpace(text[1:]))
+		return v, ImmediateMode, err
+	case strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]"):
+		inner := strings.TrimSpace(text[1 : len(text)-1])
+		if inner == "rb" {
+			return 0, RelativeMode, nil
+		}
+
+		if strings.HasPrefix(inner, "rb") {
+			rest := strings.TrimSpace(inner[2:])
+			if !strings.HasPrefix(rest, "+") && !strings.HasPrefix(rest, "-") {
+				return 0, 0, fmt.Errorf("invalid relative operand %s", text)
+			}
+
+			v, err := a.evaluate(strings.TrimSpace(rest[1:]))
+			if rest[0] == '-' {
+				v = -v
+			}
+			return v, RelativeMode, err
+		}
+
+		v, err := a.evaluate(inner)
+		return v, PositionMode, err
+	}
+
+	if !strings.HasPrefix(text, "@") {
+		return 0, 0, fmt.Errorf("operand %s has no mode; use #%s for a value or [%s] for an address", text, text, text)
+	}
+
+	v, err := a.evaluate(text)
+	return v, ImmediateMode, err
+}
+
+// evaluate resolves an integer or a label reference with an optional offset
+func (a *assembler) evaluate(text string) (int, error) {
+	if !strings.HasPrefix(text, "@") {
+		v, err := strconv.Atoi(text)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %s", text)
+		}
+		return v, nil
+	}
+
+	name := text[1:]
+	offset := 0
+	if i := strings.IndexAny(name, "+-"); i >= 0 {
+		o, err := strconv.Atoi(strings.TrimSpace(name[i:]))
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset in %s", text)
+		}
+
+		offset = o
+		name = strings.TrimSpace(name[:i])
+	}
+
+	address, ok := a.labels[name]
+	if !ok {
+		return 0, fmt.Errorf("undefined label %s", name)
+	}
+
+	return address + offset, nil
+}
+
+func stripComment(text string) string {
+	if i := strings.Index(text, ";"); i >= 0 {
+		text = text[:i]
+	}
+
+	return strings.TrimSpace(text)
+}
+
+// splitStatement separates the instruction name from its comma separated
+// operands
+func splitStatement(text string) (string, []string) {
+	name, rest := text, ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		name, rest = text[:i], strings.TrimSpace(text[i:])
+	}
+
+	operands := []string{}
+	if rest != "" {
+		for _, o := range strings.Split(rest, ",") {
+			operands = append(operands, strings.TrimSpace(o))
+		}
+	}
+
+	return name, operands
+}
+
+// WriteCodes writes the program as comma separated values readable by Load
+func WriteCodes(w io.Writer, set []int) error {
+	values := make([]string, len(set))
+	for i, v := range set {
+		values[i] = strconv.Itoa(v)
+	}
+
+	_, err := fmt.Fprintln(w, strings.Join(values, ","))
+	return err
+}
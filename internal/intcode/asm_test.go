@@ -0,0 +1,140 @@
+package intcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAssemble(t *testing.T) {
+	inputs := []string{
+		"in [0]\nout [0]\nhlt",
+		"add #1, #2, [rb+3] ; comment\nmul [rb-1], @end, [rb]\nend: hlt",
+		"jt #1, @next\nnext:\n.data 5, @next, -3",
+		".macro inc cell\nadd [\\cell], #1, [\\cell]\n.endm\ninc 7\nhlt\n.data 4"}
+	expected := [][]int{
+		[]int{3, 0, 4, 0, 99},
+		[]int{21101, 1, 2, 3, 21202, -1, 8, 0, 99},
+		[]int{1105, 1, 3, 5, 3, -3},
+		[]int{1001, 7, 1, 7, 99, 4}}
+
+	for i, input := range inputs {
+		codes, err := AssembleString(input)
+		if err != nil {
+			t.Errorf("unexpected error returned for test %v: %s", i+1, err.Error())
+			continue
+		}
+
+		if len(codes) != len(expected[i]) {
+			t.Errorf("incorrect program %v for test %v; expected %v", codes, i+1, expected[i])
+			continue
+		}
+
+		for j, v := range expected[i] {
+			if codes[j] != v {
+				t.Errorf("incorrect program %v for test %v; expected %v", codes, i+1, expected[i])
+				break
+			}
+		}
+	}
+}
+
+func TestAssemble_Run(t *testing.T) {
+	src := `
+; count down from the input value to zero
+.macro dec cell
+	add [\cell], #-1, [\cell]
+.endm
+
+	in [@counter]
+loop:
+	out [@counter]
+	jf [@counter], @done
+	dec @counter
+	jt #1, @loop
+done:
+	hlt
+counter: .data 0
+`
+	expected := "3\n2\n1\n0\n"
+
+	codes, err := AssembleString(src)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	w := bytes.NewBuffer([]byte{})
+	result := Process(strings.NewReader("3\n"), w, 0, codes)
+	if result.Status != Halted {
+		t.Errorf("incorrect status %v; expected %v", result.Status, Halted)
+	}
+
+	if w.String() != expected {
+		t.Errorf("incorrect output %q; expected %q", w.String(), expected)
+	}
+}
+
+func TestAssemble_Disassembly(t *testing.T) {
+	set := []int{109, -1, 1002, 3, 10, 7, 21101, 1, 2, 0, 204, 3, 1105, 1, 0, 99, 98, 1}
+
+	src := new(bytes.Buffer)
+	for _, line := range Disassemble(set) {
+		src.WriteString(line.Mnemonic + " " + strings.Join(line.Operands, ", ") + "\n")
+	}
+
+	codes, err := Assemble(src)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	if len(codes) != len(set) {
+		t.Errorf("incorrect program %v; expected %v", codes, set)
+		return
+	}
+
+	for i, v := range set {
+		if codes[i] != v {
+			t.Errorf("incorrect value %v at address %v; expected %v", codes[i], i, v)
+		}
+	}
+}
+
+func TestAssemble_Errors(t *testing.T) {
+	inputs := []string{
+		"hlt\nfoo #1",
+		"add #1, #2",
+		"jt #1, @missing",
+		"a: hlt\na: hlt",
+		".macro m x\nhlt",
+		".macro m x\nm \\x\n.endm\nm 1",
+		"\n\n.data",
+		"hlt\nadd 1, 2, [3]"}
+	expected := []int{2, 1, 1, 2, 1, 4, 3, 2}
+
+	for i, input := range inputs {
+		_, err := AssembleString(input)
+		ae, ok := err.(*AsmError)
+		if !ok {
+			t.Errorf("expected assembler error for test %v; got %v", i+1, err)
+			continue
+		}
+
+		if ae.Line != expected[i] {
+			t.Errorf("incorrect error line %v for test %v; expected %v", ae.Line, i+1, expected[i])
+		}
+	}
+}
+
+func TestWriteCodes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := WriteCodes(buf, []int{1, -2, 99})
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+	}
+
+	if buf.String() != "1,-2,99\n" {
+		t.Errorf("incorrect output %q; expected %q", buf.String(), "1,-2,99\n")
+	}
+}
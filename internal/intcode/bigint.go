@@ -0,0 +1,370 @@
+package intcode
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// BigMachine runs a program with arbitrary precision values. Its Run behaves
+// like that of Machine, except that values are *big.Int and memory is entirely
+// sparse. Addresses, jump targets and relative base adjustments must still fit
+// in an int.
+//
+// BigMachine is a separate interpreter rather than an arithmetic mode of
+// Machine. It decodes with DefaultOps and takes the arity, written parameter
+// and input, output and halt flags of every opcode from there, but only the
+// standard opcodes have big handlers; registered opcodes fail when executed.
+// Tracers, budgets, contexts, snapshots and forks are not supported. Use a
+// Machine with CheckedArithmetic to detect programs that need it.
+type BigMachine struct {
+	MaxAddress int
+	memory     map[int]*big.Int
+	position   int
+	relPos     int
+	input      []*big.Int
+	output     []*big.Int
+	halted     bool
+	fault      *Fault
+	steps      int
+	// next is the address of the following instruction, which jumps change
+	next int
+}
+
+// NewBigMachine ...
+func NewBigMachine(set []int) *BigMachine {
+	m := &BigMachine{
+		MaxAddress: DefaultMaxAddress,
+		memory:     make(map[int]*big.Int)}
+
+	for i, v := range set {
+		if v != 0 {
+			m.memory[i] = big.NewInt(int64(v))
+		}
+	}
+
+	return m
+}
+
+// Feed queues values to be read by input instructions
+func (m *BigMachine) Feed(values ...*big.Int) {
+	for _, v := range values {
+		m.input = append(m.input, new(big.Int).Set(v))
+	}
+}
+
+// Output removes and returns the oldest output value
+func (m *BigMachine) Output() (*big.Int, bool) {
+	if len(m.output) == 0 {
+		return nil, false
+	}
+
+	v := m.output[0]
+	m.output = m.output[1:]
+	return v, true
+}
+
+// Peek returns the value at address without changing memory
+func (m *BigMachine) Peek(address int) (*big.Int, error) {
+	v, err := m.load(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Set(v), nil
+}
+
+// Steps returns the number of completed instructions, including the one the
+// machine halted at
+func (m *BigMachine) Steps() int {
+	return m.steps
+}
+
+func (m *BigMachine) load(address int) (*big.Int, error) {
+	if address < 0 || address > m.MaxAddress {
+		return nil, &AddressError{Address: address, MaxAddress: m.MaxAddress}
+	}
+
+	v, ok := m.memory[address]
+	if !ok {
+		return new(big.Int), nil
+	}
+
+	return v, nil
+}
+
+func (m *BigMachine) store(address int, value *big.Int) error {
+	if address < 0 || address > m.MaxAddress {
+		return &AddressError{Address: address, MaxAddress: m.MaxAddress}
+	}
+
+	m.memory[address] = value
+	return nil
+}
+
+// toInt converts a value used as an address or jump target
+func toInt(v *big.Int) (int, error) {
+	if !v.IsInt64() || v.Int64() > maxInt || v.Int64() < minInt {
+		return 0, fmt.Errorf("value %s does not fit in an address", v.String())
+	}
+
+	return int(v.Int64()), nil
+}
+
+// address resolves the memory address a position or relative parameter
+// refers to; immediate parameters refer to their own address
+func (m *BigMachine) address(index int, mode ParameterMode) (int, error) {
+	pos := m.position + 1 + index
+	if mode == ImmediateMode {
+		return pos, nil
+	}
+
+	p, err := m.load(pos)
+	if err != nil {
+		return 0, err
+	}
+
+	a, err := toInt(p)
+	if err != nil {
+		return 0, err
+	}
+
+	if mode == RelativeMode {
+		r, ok := CheckedAdd(m.relPos, a)
+		if !ok {
+			return 0, &OverflowError{Op: RelativeBase, A: m.relPos, B: a}
+		}
+		a = r
+	}
+
+	return a, nil
+}
+
+func (m *BigMachine) value(index int, mode ParameterMode) (*big.Int, error) {
+	a, err := m.address(index, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.load(a)
+}
+
+// Run returns Failed together with a *Fault when an instruction cannot be
+// executed. A failed machine keeps returning the same fault.
+func (m *BigMachine) Run() (Status, error) {
+	if m.halted {
+		return Halted, nil
+	}
+
+	if m.fault != nil {
+		return Failed, m.fault
+	}
+
+	for {
+		status, err := m.step()
+		if err != nil {
+			m.fault = &Fault{Position: m.position, Reason: err.Error()}
+			if v, err := m.load(m.position); err == nil && v.IsInt64() {
+				m.fault.Op = int(v.Int64())
+			}
+			return Failed, m.fault
+		}
+
+		if status != 0 {
+			return status, nil
+		}
+	}
+}
+
+// bigHandler executes a decoded instruction and returns the value to store in
+// its written parameter, if it has one
+type bigHandler func(m *BigMachine, modes []ParameterMode) (*big.Int, error)
+
+// bigHandlers implements the standard opcodes with big values
+var bigHandlers = map[OpCode]bigHandler{
+	AddOp:        bigAdd,
+	MultiplyOp:   bigMult,
+	InputOp:      bigInput,
+	OutputOp:     bigOutput,
+	JumpTrue:     bigJump(true),
+	JumpFalse:    bigJump(false),
+	LessThan:     bigLessThan,
+	Equals:       bigEquals,
+	RelativeBase: bigRelativeBase}
+
+// step executes one instruction. It returns a status when Run should return.
+func (m *BigMachine) step() (Status, error) {
+	v, err := m.load(m.position)
+	if err != nil {
+		return 0, err
+	}
+
+	if !v.IsInt64() || v.Int64() > maxInt {
+		msg := fmt.Sprintf("instruction %s has too many digits", v.String())
+		return 0, &DecodeError{Address: m.position, Digit: modeCount + 2, Reason: msg}
+	}
+
+	code, modes, err := DefaultOps.Decode(int(v.Int64()), m.position)
+	if err != nil {
+		return 0, err
+	}
+
+	op, _ := DefaultOps.Lookup(code)
+	if op.Halts {
+		m.steps++
+		m.halted = true
+		return Halted, nil
+	}
+
+	if op.ReadsInput && len(m.input) == 0 {
+		return NeedsInput, nil
+	}
+
+	handler, ok := bigHandlers[code]
+	if !ok {
+		return 0, fmt.Errorf("opcode %v is not supported with big values", int(code))
+	}
+
+	m.next = m.position + op.Arity + 1
+	result, err := handler(m, modes)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, w := range op.Writes {
+		a, err := m.address(w, modes[w])
+		if err != nil {
+			return 0, err
+		}
+
+		err = m.store(a, result)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	m.steps++
+	m.position = m.next
+
+	if op.WritesOutput {
+		return HasOutput, nil
+	}
+
+	return 0, nil
+}
+
+// values reads the first n parameters
+func (m *BigMachine) values(modes []ParameterMode, n int) ([]*big.Int, error) {
+	args := make([]*big.Int, n)
+	for j := range args {
+		var err error
+		args[j], err = m.value(j, modes[j])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return args, nil
+}
+
+func bigAdd(m *BigMachine, modes []ParameterMode) (*big.Int, error) {
+	args, err := m.values(modes, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Add(args[0], args[1]), nil
+}
+
+func bigMult(m *BigMachine, modes []ParameterMode) (*big.Int, error) {
+	args, err := m.values(modes, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Mul(args[0], args[1]), nil
+}
+
+func bigInput(m *BigMachine, modes []ParameterMode) (*big.Int, error) {
+	v := m.input[0]
+	m.input = m.input[1:]
+	return v, nil
+}
+
+func bigOutput(m *BigMachine, modes []ParameterMode) (*big.Int, error) {
+	args, err := m.values(modes, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	m.output = append(m.output, new(big.Int).Set(args[0]))
+	return nil, nil
+}
+
+// bigJump jumps when the first parameter is non-zero, or zero when nonZero is
+// false. The target is only read when the jump is taken.
+func bigJump(nonZero bool) bigHandler {
+	return func(m *BigMachine, modes []ParameterMode) (*big.Int, error) {
+		args, err := m.values(modes, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if (args[0].Sign() != 0) != nonZero {
+			return nil, nil
+		}
+
+		target, err := m.value(1, modes[1])
+		if err != nil {
+			return nil, err
+		}
+
+		m.next, err = toInt(target)
+		return nil, err
+	}
+}
+
+func bigLessThan(m *BigMachine, modes []ParameterMode) (*big.Int, error) {
+	args, err := m.values(modes, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if args[0].Cmp(args[1]) < 0 {
+		return big.NewInt(1), nil
+	}
+
+	return big.NewInt(0), nil
+}
+
+func bigEquals(m *BigMachine, modes []ParameterMode) (*big.Int, error) {
+	args, err := m.values(modes, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if args[0].Cmp(args[1]) == 0 {
+		return big.NewInt(1), nil
+	}
+
+	return big.NewInt(0), nil
+}
+
+func bigRelativeBase(m *BigMachine, modes []ParameterMode) (*big.Int, error) {
+	args, err := m.values(modes, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := toInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := CheckedAdd(m.relPos, a)
+	if !ok {
+		return nil, &OverflowError{Op: RelativeBase, A: m.relPos, B: a}
+	}
+
+	m.relPos = r
+	return nil, nil
+}
@@ -0,0 +1,75 @@
+package intcode
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigMachine(t *testing.T) {
+	inputs := [][]int{
+		[]int{109, 1, 204, -1, 1001, 100, 1, 100, 1008, 100, 16, 101, 1006, 101, 0, 99},
+		[]int{3, 9, 8, 9, 10, 9, 4, 9, 99, -1, 8},
+		[]int{1102, 34915192, 34915192, 7, 4, 7, 99, 0},
+		[]int{1102, 4611686018427387904, 4, 11, 2, 11, 11, 11, 4, 11, 99}}
+	expected := [][]string{
+		[]string{"109", "1", "204", "-1", "1001", "100", "1", "100", "1008", "100", "16", "101", "1006", "101", "0", "99"},
+		[]string{"1"},
+		[]string{"1219070632396864"},
+		[]string{"340282366920938463463374607431768211456"}}
+
+	for i, set := range inputs {
+		m := NewBigMachine(set)
+		result := []string{}
+
+		for done := false; !done; {
+			status, err := m.Run()
+			if err != nil {
+				t.Errorf("unexpected error returned for test %v: %s", i+1, err.Error())
+				break
+			}
+
+			switch status {
+			case NeedsInput:
+				m.Feed(big.NewInt(8))
+			case HasOutput:
+				v, _ := m.Output()
+				result = append(result, v.String())
+			case Halted:
+				done = true
+			}
+		}
+
+		if len(result) != len(expected[i]) {
+			t.Errorf("incorrect output %v for test %v; expected %v", result, i+1, expected[i])
+			continue
+		}
+
+		for j, v := range expected[i] {
+			if result[j] != v {
+				t.Errorf("incorrect output %v for test %v; expected %v", result, i+1, expected[i])
+				break
+			}
+		}
+	}
+}
+
+func TestBigMachine_Fault(t *testing.T) {
+	inputs := [][]int{
+		[]int{1102, 4611686018427387904, 4, 9, 105, 1, 9, 99},
+		[]int{1102, 4611686018427387904, 4, 20, 2, 20, 20, 20, 5, 20, 20, 99},
+		[]int{42}}
+	expected := []int{4, 8, 0}
+
+	for i, set := range inputs {
+		m := NewBigMachine(set)
+		status, err := m.Run()
+		if status != Failed {
+			t.Errorf("incorrect status %v for test %v; expected %v", status, i+1, Failed)
+			continue
+		}
+
+		if f := err.(*Fault); f.Position != expected[i] {
+			t.Errorf("incorrect fault position %v for test %v; expected %v", f.Position, i+1, expected[i])
+		}
+	}
+}
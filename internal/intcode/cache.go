@@ -0,0 +1,157 @@
+package intcode
+
+// decoded is an instruction as Step leaves it, kept so that executing the same
+// address again needs neither decoding nor allocation
+type decoded struct {
+	op     *OpInfo
+	modes  []ParameterMode
+	params []Parameter
+}
+
+// decodeCache holds the decoded instruction at each address. Addresses in the
+// loaded program are looked up directly; those in paged memory are kept a
+// page at a time like Memory. Pages may be shared with the cache of a forked
+// instruction, in which case they are copied on the first change.
+type decodeCache struct {
+	ops     *OpTable
+	program []*decoded
+	pages   map[int][]*decoded
+	shared  map[int]bool
+	// last is the most recently used page, which saves a map lookup for
+	// most instructions
+	last      []*decoded
+	lastIndex int
+}
+
+func newDecodeCache(ops *OpTable, size int) *decodeCache {
+	return &decodeCache{
+		ops:     ops,
+		program: make([]*decoded, size),
+		pages:   make(map[int][]*decoded),
+		shared:  make(map[int]bool)}
+}
+
+func (c *decodeCache) entry(address int) *decoded {
+	if address >= 0 && address < len(c.program) {
+		return c.program[address]
+	}
+
+	if address < 0 || len(c.pages) == 0 {
+		return nil
+	}
+
+	index := address / pageSize
+	if c.last == nil || c.lastIndex != index {
+		page, ok := c.pages[index]
+		if !ok {
+			return nil
+		}
+		c.last, c.lastIndex = page, index
+	}
+
+	return c.last[address%pageSize]
+}
+
+func (c *decodeCache) set(address int, d *decoded) {
+	if address < len(c.program) {
+		c.program[address] = d
+		return
+	}
+
+	c.writable(address / pageSize)[address%pageSize] = d
+}
+
+// writable returns the page at index for changing, allocating or copying it
+// as needed
+func (c *decodeCache) writable(index int) []*decoded {
+	page, ok := c.pages[index]
+	switch {
+	case !ok:
+		page = make([]*decoded, pageSize)
+		c.pages[index] = page
+	case c.shared[index]:
+		page = append(make([]*decoded, 0, pageSize), page...)
+		c.pages[index] = page
+		delete(c.shared, index)
+	}
+
+	c.last, c.lastIndex = page, index
+	return page
+}
+
+// pageProgram moves the entries of the loaded program into pages, following
+// the program into paged memory
+func (c *decodeCache) pageProgram() {
+	for start := 0; start < len(c.program); start += pageSize {
+		page := make([]*decoded, pageSize)
+		copy(page, c.program[start:])
+		c.pages[start/pageSize] = page
+		delete(c.shared, start/pageSize)
+	}
+
+	c.program = nil
+	c.last = nil
+}
+
+// fork returns a copy of the cache that shares every page with c until either
+// side changes it. The program must already be paged.
+func (c *decodeCache) fork() *decodeCache {
+	f := newDecodeCache(c.ops, 0)
+	for i, page := range c.pages {
+		f.pages[i] = page
+		f.shared[i] = true
+		c.shared[i] = true
+	}
+
+	return f
+}
+
+// cached returns the decoded instruction at address if it is still valid
+func (i *Instruction) cached(address int) *decoded {
+	c := i.cache
+	if c == nil || i.NoCache || c.ops != i.ops() {
+		return nil
+	}
+
+	return c.entry(address)
+}
+
+// remember caches the instruction Step just decoded, copying its modes and
+// parameters out of the instruction's buffers. Every write made by the
+// instruction invalidates the entries it overlaps.
+func (i *Instruction) remember(op *OpInfo) {
+	if i.NoCache || i.Position < 0 {
+		return
+	}
+
+	c := i.cache
+	if c == nil || c.ops != i.ops() || len(c.program) != len(i.DataSet) {
+		c = newDecodeCache(i.ops(), len(i.DataSet))
+		i.cache = c
+	}
+
+	c.set(i.Position, &decoded{
+		op:     op,
+		modes:  append([]ParameterMode{}, i.Modes...),
+		params: append([]Parameter{}, i.Parameters...)})
+}
+
+// invalidate drops every cached instruction whose values include address
+func (i *Instruction) invalidate(address int) {
+	c := i.cache
+	if c == nil {
+		return
+	}
+
+	for a := address - modeCount; a <= address; a++ {
+		if e := c.entry(a); e != nil && a+len(e.params) >= address {
+			c.set(a, nil)
+		}
+	}
+}
+
+// ClearCache drops every decoded instruction. It is needed after changing
+// DataSet or Memory directly rather than through executed instructions.
+func (i *Instruction) ClearCache() {
+	i.cache = nil
+}
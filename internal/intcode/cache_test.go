@@ -0,0 +1,90 @@
+package intcode
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCache_SelfModifying(t *testing.T) {
+	codes := [][]int{
+		// rewrites the operand of the output instruction before looping back
+		[]int{104, 1, 1101, 0, 2, 1, 1001, 20, 1, 20, 1007, 20, 2, 21, 1005, 21, 0, 99, 0, 0, 0, 0},
+		// rewrites the output instruction into a halt before looping back
+		[]int{104, 1, 1101, 0, 99, 0, 1105, 1, 0, 99}}
+	expected := []string{"1\n2\n", "1\n"}
+
+	for i, set := range codes {
+		out := new(bytes.Buffer)
+		result := Process(strings.NewReader(""), out, 0, set)
+		if result.Status != Halted {
+			t.Errorf("incorrect status %v for test %v; expected %v", result.Status, i+1, Halted)
+		}
+
+		if out.String() != expected[i] {
+			t.Errorf("incorrect output %q for test %v; expected %q", out.String(), i+1, expected[i])
+		}
+	}
+}
+
+func TestCache_Reused(t *testing.T) {
+	comp := NewInstruction(0, []int{1001, 8, -1, 8, 1005, 8, 0, 99, 3})
+	result := Run(comp)
+	if result.Status != Halted {
+		t.Errorf("incorrect status %v; expected %v", result.Status, Halted)
+		return
+	}
+
+	if comp.cache == nil || comp.cache.entry(0) == nil || comp.cache.entry(4) == nil {
+		t.Errorf("loop instructions were not cached")
+		return
+	}
+
+	if comp.cache.entry(0).params[0].Value != 8 {
+		t.Errorf("incorrect cached parameter %v; expected %v", comp.cache.entry(0).params[0].Value, 8)
+	}
+}
+
+func TestCache_Fork(t *testing.T) {
+	// outputs the value at address 1 forever
+	m := NewMachine([]int{104, 1, 1105, 1, 0})
+	m.Run()
+	m.Output()
+
+	f := m.Fork()
+	f.inst.write(1, 2)
+
+	for i, machine := range []*Machine{m, f, m, f} {
+		machine.Run()
+		v, _ := machine.Output()
+
+		expected := 1 + i%2
+		if v != expected {
+			t.Errorf("incorrect output %v from run %v; expected %v", v, i+1, expected)
+		}
+	}
+
+	// both keep using the cache once the program is paged
+	for _, machine := range []*Machine{m, f} {
+		if machine.inst.DataSet != nil || machine.inst.cache == nil || machine.inst.cached(0) == nil {
+			t.Errorf("paged program was not cached")
+		}
+	}
+}
+
+func TestCache_Direct(t *testing.T) {
+	out := new(bytes.Buffer)
+	comp := NewInstruction(0, []int{104, 1, 1105, 1, 0})
+	comp.Output = NewTextWriter(out)
+	RunContext(context.Background(), comp, 1)
+
+	// changing the program directly requires clearing the cache
+	comp.DataSet[1] = 2
+	comp.ClearCache()
+	RunContext(context.Background(), comp, 2)
+
+	if out.String() != "1\n2\n" {
+		t.Errorf("incorrect output %q; expected %q", out.String(), "1\n2\n")
+	}
+}
@@ -1,14 +1,10 @@
 package intcode
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"log"
-	"os"
-	"strconv"
-	"strings"
-	"unicode"
 )
 
 // OpCode ...
@@ -53,10 +49,70 @@ type Instruction struct {
 	Op         OpCode
 	Parameters []Parameter
 	Modes      []ParameterMode
-	Next       *int
+	// Next is where execution continues when the instruction jumped
+	Next       int
 	DataSet    []int
-	Input      *bufio.Reader
-	Output     io.Writer
+	Memory     *Memory
+	Input      ValueReader
+	Output     ValueWriter
+	Arithmetic Arithmetic
+	Tracer     Tracer
+	Ops        *OpTable
+	// NoCache decodes every instruction as it is reached instead of reusing
+	// the instruction previously decoded at the same address
+	NoCache  bool
+	jumped   bool
+	event    *TraceEvent
+	executed int
+	info     *OpInfo
+	cache    *decodeCache
+	// modeBuf and paramBuf hold the modes and parameters of instructions
+	// decoded without the cache so that Step does not allocate
+	modeBuf  [modeCount]ParameterMode
+	paramBuf [modeCount]Parameter
+}
+
+// read returns the value at address from the loaded program or, past its
+// end, from the instruction's sparse memory
+func (i *Instruction) read(address int) (int, error) {
+	if address >= 0 && address < len(i.DataSet) {
+		return i.DataSet[address], nil
+	}
+
+	return i.memory().Load(address)
+}
+
+// Peek returns the value at address without changing memory
+func (i *Instruction) Peek(address int) (int, error) {
+	return i.read(address)
+}
+
+func (i *Instruction) write(address int, value int) error {
+	if i.event != nil {
+		i.event.Writes = append(i.event.Writes, MemoryWrite{Address: address, Value: value})
+	}
+
+	if address >= 0 && address < len(i.DataSet) {
+		i.DataSet[address] = value
+		i.invalidate(address)
+		return nil
+	}
+
+	err := i.memory().Store(address, value)
+	if err != nil {
+		return err
+	}
+
+	i.invalidate(address)
+	return nil
+}
+
+func (i *Instruction) memory() *Memory {
+	if i.Memory == nil {
+		i.Memory = NewMemory(DefaultMaxAddress)
+	}
+
+	return i.Memory
 }
 
 func (i *Instruction) getValue(index int) (int, error) {
@@ -64,38 +120,22 @@ func (i *Instruction) getValue(index int) (int, error) {
 	parm := i.Parameters[index]
 	switch mode {
 	case PositionMode:
-		exp := parm.Value + 1
-		if len(i.DataSet) < exp {
-			n := make([]int, exp)
-			copy(n, i.DataSet)
-			i.DataSet = n
-		}
-		return i.DataSet[parm.Value], nil
+		return i.read(parm.Value)
 	case ImmediateMode:
 		return parm.Value, nil
 	case RelativeMode:
-		exp := i.RelPos + parm.Value + 1
-		if len(i.DataSet) < exp {
-			n := make([]int, exp)
-			copy(n, i.DataSet)
-			i.DataSet = n
+		address, err := i.add(i.RelPos, parm.Value)
+		if err != nil {
+			return 0, err
 		}
-		return i.DataSet[i.RelPos+parm.Value], nil
+		return i.read(address)
 	}
 
 	return 0, &CodeTerminationError{exitCode: 1, message: "unknown parameter mode"}
 }
 
 func (i *Instruction) getInput() (int, error) {
-	text, err := i.Input.ReadString('\n')
-	if err != nil {
-		return 0, err
-	}
-
-	t := strings.TrimRightFunc(text, func(r rune) bool {
-		return unicode.IsSpace(r)
-	})
-	return strconv.Atoi(t)
+	return i.Input.ReadValue()
 }
 
 func (i *Instruction) setValue(index int, value int) error {
@@ -104,43 +144,27 @@ func (i *Instruction) setValue(index int, value int) error {
 
 	switch mode {
 	case PositionMode:
-		exp := parm.Value + 1
-		if len(i.DataSet) < exp {
-			n := make([]int, exp)
-			copy(n, i.DataSet)
-			i.DataSet = n
-		}
-		i.DataSet[parm.Value] = value
-		return nil
+		return i.write(parm.Value, value)
 	case ImmediateMode:
-		exp := parm.Position + 1
-		if len(i.DataSet) < exp {
-			n := make([]int, exp)
-			copy(n, i.DataSet)
-			i.DataSet = n
-		}
-		i.DataSet[parm.Position] = value
-		return nil
+		return i.write(parm.Position, value)
 	case RelativeMode:
-		exp := i.RelPos + parm.Value + 1
-		if len(i.DataSet) < exp {
-			n := make([]int, exp)
-			copy(n, i.DataSet)
-			i.DataSet = n
+		address, err := i.add(i.RelPos, parm.Value)
+		if err != nil {
+			return err
 		}
-		i.DataSet[i.RelPos+parm.Value] = value
-		return nil
+		return i.write(address, value)
 	}
 
 	return &CodeTerminationError{exitCode: 1, message: "unknown parameter mode"}
 }
 
-func (i *Instruction) setOutput(out int) {
-	fmt.Fprintf(i.Output, "%v\n", out)
+func (i *Instruction) setOutput(out int) error {
+	return i.Output.WriteValue(out)
 }
 
 func (i *Instruction) setNext(position int) {
-	i.Next = &position
+	i.Next = position
+	i.jumped = true
 }
 
 func (i *Instruction) nextPosition() (int, error) {
@@ -148,30 +172,31 @@ func (i *Instruction) nextPosition() (int, error) {
 	case TerminateOp:
 		return 0, &CodeTerminationError{exitCode: 0, message: "success"}
 	default:
-		if i.Next != nil {
-			return *i.Next, nil
+		if i.jumped {
+			return i.Next, nil
 		}
 		return i.Position + len(i.Parameters) + 1, nil
 	}
 }
 
-func (i *Instruction) loadParams(code OpCode) (*[]Parameter, error) {
-	var params []Parameter
-
-	switch code {
-	case AddOp, MultiplyOp, LessThan, Equals:
-		params = GetParameters(3, i.Position+1, i.DataSet)
-	case JumpTrue, JumpFalse:
-		params = GetParameters(2, i.Position+1, i.DataSet)
-	case InputOp, OutputOp, RelativeBase:
-		params = GetParameters(1, i.Position+1, i.DataSet)
-	case TerminateOp:
-		params = []Parameter{}
-	default:
+func (i *Instruction) loadParams(code OpCode) ([]Parameter, error) {
+	quantity, ok := i.ops().Arity(code)
+	if !ok {
 		return nil, &CodeTerminationError{exitCode: 1, message: "unknown opcode"}
 	}
 
-	return &params, nil
+	params := i.paramBuf[:quantity]
+	for j := range params {
+		pos := i.Position + 1 + j
+		v, err := i.read(pos)
+		if err != nil {
+			return nil, err
+		}
+
+		params[j] = Parameter{Value: v, Position: pos}
+	}
+
+	return params, nil
 }
 
 // Step ...
@@ -181,157 +206,87 @@ func (i *Instruction) Step() error {
 		return err
 	}
 
-	code, modes := SplitOp(i.DataSet[pos])
-	i.Op = code
 	i.Position = pos
-	i.Next = nil
-	p, err := i.loadParams(i.Op)
+	if d := i.cached(pos); d != nil {
+		i.Op = d.op.Code
+		i.jumped = false
+		i.info = d.op
+		i.Parameters = d.params
+		i.Modes = d.modes
+		return nil
+	}
+
+	value, err := i.read(pos)
 	if err != nil {
 		return err
 	}
 
-	i.Parameters = *p
-	i.Modes = modes
+	code, err := i.ops().decode(value, pos, i.modeBuf[:])
 	if err != nil {
-		return nil
+		return err
+	}
+
+	i.Op = code
+	i.jumped = false
+	p, err := i.loadParams(i.Op)
+	if err != nil {
+		return err
 	}
 
+	i.Parameters = p
+	i.Modes = i.modeBuf[:]
+	i.info, _ = i.ops().Lookup(code)
+	i.remember(i.info)
+
 	return nil
 }
 
-// Exec ...
+// Exec runs the decoded instruction, reporting it to the Tracer if one is
+// set. Only instructions that complete, including a halt, are counted as
+// executed; one that fails or finds no input is not.
 func (i *Instruction) Exec() error {
-	switch i.Op {
-	case AddOp:
-		v1, err := i.getValue(0)
-		if err != nil {
-			return err
-		}
-
-		v2, err := i.getValue(1)
-		if err != nil {
-			return err
-		}
-
-		r := Add(v1, v2)
-		if err != nil {
-			return err
-		}
-
-		err = i.setValue(2, r)
-		if err != nil {
-			return err
-		}
-	case MultiplyOp:
-		v1, err := i.getValue(0)
-		if err != nil {
-			return err
-		}
-
-		v2, err := i.getValue(1)
-		if err != nil {
-			return err
-		}
-
-		r := Mult(v1, v2)
-
-		err = i.setValue(2, r)
-		if err != nil {
-			return err
-		}
-	case InputOp:
-		input, err := i.getInput()
-		if err != nil {
-			return err
-		}
-
-		i.setValue(0, input)
-	case OutputOp:
-		v1, err := i.getValue(0)
-		if err != nil {
-			return err
-		}
-
-		i.setOutput(v1)
-	case JumpTrue:
-		v1, err := i.getValue(0)
-		if err != nil {
-			return err
-		}
-
-		// jump to position if value is not zero
-		if v1 != 0 {
-			v2, err := i.getValue(1)
-			if err != nil {
-				return err
-			}
-
-			i.setNext(v2)
-		}
-	case JumpFalse:
-		v1, err := i.getValue(0)
-		if err != nil {
-			return err
-		}
+	if i.Tracer == nil {
+		err := i.exec()
+		i.complete(err)
+		return err
+	}
 
-		// jump to position if value is zero
-		if v1 == 0 {
-			v2, err := i.getValue(1)
-			if err != nil {
-				return err
-			}
+	e := i.traceEvent()
+	i.Tracer.Before(e)
 
-			i.setNext(v2)
-		}
-	case LessThan:
-		v1, err := i.getValue(0)
-		if err != nil {
-			return err
-		}
+	i.event = e
+	err := i.exec()
+	i.event = nil
+	i.complete(err)
 
-		v2, err := i.getValue(1)
-		if err != nil {
-			return err
-		}
+	e.RelBaseAfter = i.RelPos
+	if err != nil && !terminated(err) {
+		e.Error = err.Error()
+	}
+	i.Tracer.After(e)
 
-		// if first value is less than second value, set third value to 1 else 0
-		if v1 < v2 {
-			i.setValue(2, 1)
-		} else {
-			i.setValue(2, 0)
-		}
-	case Equals:
-		v1, err := i.getValue(0)
-		if err != nil {
-			return err
-		}
+	return err
+}
 
-		v2, err := i.getValue(1)
-		if err != nil {
-			return err
-		}
+// complete counts the instruction if it ran without error or halted
+func (i *Instruction) complete(err error) {
+	if err == nil || terminated(err) {
+		i.executed++
+	}
+}
 
-		// if first value is equal to second value, set third value to 1 else 0
-		if v1 == v2 {
-			i.setValue(2, 1)
-		} else {
-			i.setValue(2, 0)
-		}
-	case RelativeBase:
-		v1, err := i.getValue(0)
-		if err != nil {
-			return err
+func (i *Instruction) exec() error {
+	op := i.info
+	if op == nil || op.Code != i.Op {
+		var ok bool
+		op, ok = i.ops().Lookup(i.Op)
+		if !ok {
+			m := fmt.Sprintf("unknown instruction code %v", i.Op)
+			return &CodeTerminationError{exitCode: 1, message: m}
 		}
-
-		i.RelPos = i.RelPos + v1
-	case TerminateOp:
-		return &CodeTerminationError{exitCode: 0, message: "success"}
-	default:
-		m := fmt.Sprintf("unknown instruction code %v", i.Op)
-		return &CodeTerminationError{exitCode: 1, message: m}
 	}
 
-	return nil
+	return op.Handler(i)
 }
 
 // Parameter ...
@@ -340,67 +295,44 @@ type Parameter struct {
 	Position int
 }
 
-// SplitOp ...
+// SplitOp decodes an instruction value without validating it. Unknown
+// opcodes are returned as is and invalid mode digits as PositionMode; use
+// DecodeOp to detect either.
 func SplitOp(opcode int) (OpCode, []ParameterMode) {
-	padded := []byte(fmt.Sprintf("%6v", strconv.Itoa(opcode)))
-
-	g := strings.TrimLeftFunc(string(padded[4:]), func(r rune) bool {
-		return unicode.IsSpace(r)
-	})
-	oc64, _ := strconv.ParseInt(g, 10, 64)
-	op := int(oc64)
-
-	params := []ParameterMode{}
-	for i := 3; i >= 0; i-- {
-		p, _ := strconv.Atoi(string(padded[i]))
-		var mode ParameterMode
-		switch p {
-		case 2:
-			mode = RelativeMode
-		case 1:
-			mode = ImmediateMode
-		case 0:
-		default:
-			mode = PositionMode
-		}
-		params = append(params, mode)
+	code, modes, err := DecodeOp(opcode, -1)
+	if err == nil {
+		return code, modes
 	}
 
-	var oc OpCode
-	switch op {
-	case 1:
-		oc = AddOp
-	case 2:
-		oc = MultiplyOp
-	case 3:
-		oc = InputOp
-	case 4:
-		oc = OutputOp
-	case 5:
-		oc = JumpTrue
-	case 6:
-		oc = JumpFalse
-	case 7:
-		oc = LessThan
-	case 8:
-		oc = Equals
-	case 9:
-		oc = RelativeBase
-	case 99:
-		oc = TerminateOp
-	default:
-		panic(fmt.Sprintf("incorrect op code: %v; for %v", op, opcode))
+	modes = make([]ParameterMode, modeCount)
+	rest := opcode / 100
+	for i := 0; i < modeCount; i++ {
+		switch ParameterMode(rest % 10) {
+		case ImmediateMode:
+			modes[i] = ImmediateMode
+		case RelativeMode:
+			modes[i] = RelativeMode
+		}
+		rest = rest / 10
 	}
 
-	return oc, params
+	return OpCode(opcode % 100), modes
 }
 
-// GetParameters ...
+// GetParameters reads quantity parameters starting at position. Parameters
+// past the end of the set read as zero and a negative quantity reads none.
 func GetParameters(quantity int, position int, set []int) []Parameter {
+	if quantity < 0 {
+		quantity = 0
+	}
+
 	params := make([]Parameter, quantity)
 
-	for j, p := range set[position : position+len(params)] {
-		params[j] = Parameter{Value: p, Position: j + position}
+	for j := range params {
+		params[j] = Parameter{Position: j + position}
+		if j+position >= 0 && j+position < len(set) {
+			params[j].Value = set[j+position]
+		}
 	}
 
 	return params
@@ -416,28 +348,13 @@ func Mult(i, j int) int {
 	return i * j
 }
 
-// ReadCodes ...
+// ReadCodes loads the program at path and exits the process if it cannot be
+// read; use LoadFile to handle the error instead
 func ReadCodes(path string) []int {
-	codes := []int{}
-
-	file, err := os.Open(path)
+	codes, err := LoadFile(path)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		values := strings.Split(scanner.Text(), ",")
-		for _, v := range values {
-			vi, _ := strconv.Atoi(v)
-			codes = append(codes, vi)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
-	}
 
 	return codes
 }
@@ -453,25 +370,78 @@ func (e *CodeTerminationError) Error() string {
 	return fmt.Sprintf("execution terminated with exit code %v; %s", e.exitCode, e.message)
 }
 
+// NewInstruction prepares the program to be decoded from startPosition by
+// the first call to Step
+func NewInstruction(startPosition int, dataSet []int) *Instruction {
+	return newInstructionSet(startPosition, dataSet)
+}
+
 func newInstructionSet(startPosition int, dataSet []int) *Instruction {
 	inst := &Instruction{
 		Position: -1,
 		DataSet:  dataSet,
-		Next:     &startPosition}
+		Next:     startPosition,
+		jumped:   true}
 
 	return inst
 }
 
-// Process ...
-func Process(in io.Reader, out io.Writer, position int, set []int) {
+// Process runs the program using newline delimited decimal text for input
+// and output
+func Process(in io.Reader, out io.Writer, position int, set []int) Result {
+	comp := newInstructionSet(position, set)
+	comp.Input = NewTextReader(in)
+	comp.Output = NewTextWriter(out)
+
+	return Run(comp)
+}
+
+// ProcessChan runs the program reading input values from in and writing
+// output values to out. The output channel is closed when the program stops.
+func ProcessChan(in <-chan int, out chan<- int, position int, set []int) Result {
+	return ProcessChanContext(context.Background(), in, out, position, set, 0)
+}
+
+// ProcessChanContext is ProcessChan with cancellation and an instruction
+// budget; a budget of zero is unlimited. Waiting on either channel also stops
+// when ctx is done.
+func ProcessChanContext(ctx context.Context, in <-chan int, out chan<- int, position int, set []int, budget int) Result {
+	defer close(out)
+
 	comp := newInstructionSet(position, set)
-	reader := bufio.NewReader(in)
+	comp.Input = &ChanReader{ch: in, ctx: ctx}
+	comp.Output = &ChanWriter{ch: out, ctx: ctx}
 
-	comp.Input = reader
-	comp.Output = out
+	return RunContext(ctx, comp, budget)
+}
+
+// Run decodes and executes instructions until the program stops
+func Run(comp *Instruction) Result {
+	return RunContext(context.Background(), comp, 0)
+}
+
+// RunContext runs until the program stops, ctx is done or budget
+// instructions have been executed; a budget of zero is unlimited. The checks
+// happen between instructions so a stopped run can be resumed by calling
+// RunContext again.
+func RunContext(ctx context.Context, comp *Instruction, budget int) Result {
+	done := ctx.Done()
+	start := comp.executed
 
 	var err error
 	for {
+		if budget > 0 && comp.executed-start >= budget {
+			return Result{Status: BudgetExhausted, Steps: comp.executed}
+		}
+
+		if done != nil {
+			select {
+			case <-done:
+				return Result{Status: Cancelled, Steps: comp.executed}
+			default:
+			}
+		}
+
 		err = comp.Step()
 		if err != nil {
 			break
@@ -482,4 +452,6 @@ func Process(in io.Reader, out io.Writer, position int, set []int) {
 			break
 		}
 	}
+
+	return newResult(comp, err)
 }
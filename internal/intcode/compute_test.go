@@ -3,9 +3,11 @@ package intcode
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSplitOp(t *testing.T) {
@@ -198,7 +200,7 @@ func TestSetOutput(t *testing.T) {
 
 	for i, d := range data {
 		buf := new(bytes.Buffer)
-		comp.Output = buf
+		comp.Output = NewTextWriter(buf)
 		comp.setOutput(d)
 		result := strings.TrimRight(fmt.Sprintf("%v", buf.String()), "\n")
 		if result != expected[i] {
@@ -216,7 +218,7 @@ func TestGetInput(t *testing.T) {
 	for i, d := range data {
 		r := bytes.NewReader([]byte{d, '\n'})
 		reader := bufio.NewReader(r)
-		comp.Input = reader
+		comp.Input = NewTextReader(reader)
 		result, err := comp.getInput()
 		if err != nil {
 			t.Errorf("input error occurred: %s", err.Error())
@@ -305,3 +307,188 @@ func TestFullIntegration(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessChan(t *testing.T) {
+	inputs := [][]int{
+		[]int{3, 0, 4, 0, 99},
+		[]int{3, 0, 1, 0, 0, 0, 4, 0, 99},
+		[]int{3, 9, 8, 9, 10, 9, 4, 9, 99, -1, 8},
+		[]int{104, 1125899906842624, 99}}
+	expected := [][]int{
+		[]int{4},
+		[]int{8},
+		[]int{0},
+		[]int{1125899906842624}}
+
+	for i, set := range inputs {
+		in := make(chan int, 1)
+		out := make(chan int)
+		in <- 4
+
+		go ProcessChan(in, out, 0, set)
+
+		result := []int{}
+		for v := range out {
+			result = append(result, v)
+		}
+
+		if len(result) != len(expected[i]) {
+			t.Errorf("incorrect output length %v for test %v; expected %v", len(result), i+1, len(expected[i]))
+			continue
+		}
+
+		for j, v := range expected[i] {
+			if result[j] != v {
+				t.Errorf("incorrect result %v for test %v; expected %v", result[j], i+1, v)
+			}
+		}
+	}
+}
+
+func TestProcess_Result(t *testing.T) {
+	inputs := [][]int{
+		[]int{3, 0, 4, 0, 99},
+		[]int{3, 0, 3, 0, 99},
+		[]int{1, 0, 0, 0, 3, 0, 99}}
+	data := []string{"4\n", "4\n", "a\n"}
+	expected := []Result{
+		Result{Status: Halted},
+		Result{Status: NeedsInput},
+		Result{Status: Failed, Fault: &Fault{Position: 4, Op: 3}}}
+
+	for i, set := range inputs {
+		br := strings.NewReader(data[i])
+		w := bytes.NewBuffer([]byte{})
+
+		result := Process(br, w, 0, set)
+		if result.Status != expected[i].Status {
+			t.Errorf("incorrect status %v for test %v; expected %v", result.Status, i+1, expected[i].Status)
+			continue
+		}
+
+		if expected[i].Fault == nil {
+			if result.Fault != nil {
+				t.Errorf("unexpected fault for test %v: %s", i+1, result.Fault.Error())
+			}
+			continue
+		}
+
+		if result.Fault == nil {
+			t.Errorf("missing fault for test %v", i+1)
+			continue
+		}
+
+		if result.Fault.Position != expected[i].Fault.Position {
+			t.Errorf("incorrect fault position %v for test %v; expected %v", result.Fault.Position, i+1, expected[i].Fault.Position)
+		}
+
+		if result.Fault.Op != expected[i].Fault.Op {
+			t.Errorf("incorrect fault op %v for test %v; expected %v", result.Fault.Op, i+1, expected[i].Fault.Op)
+		}
+	}
+}
+
+func TestRunContext_Budget(t *testing.T) {
+	budgets := []int{1, 100, 0}
+	sets := [][]int{
+		[]int{1105, 1, 0},
+		[]int{1105, 1, 0},
+		[]int{1101, 1, 1, 0, 99}}
+	expected := []Result{
+		Result{Status: BudgetExhausted, Steps: 1},
+		Result{Status: BudgetExhausted, Steps: 100},
+		Result{Status: Halted, Steps: 2}}
+
+	for i, set := range sets {
+		comp := NewInstruction(0, set)
+		result := RunContext(context.Background(), comp, budgets[i])
+
+		if result.Status != expected[i].Status || result.Steps != expected[i].Steps {
+			t.Errorf("incorrect result %v after %v steps for test %v; expected %v after %v steps", result.Status, result.Steps, i+1, expected[i].Status, expected[i].Steps)
+		}
+	}
+}
+
+func TestRunContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	comp := NewInstruction(0, []int{1105, 1, 0})
+	result := RunContext(ctx, comp, 0)
+	if result.Status != Cancelled || result.Steps != 0 {
+		t.Errorf("incorrect result %v after %v steps; expected %v after 0 steps", result.Status, result.Steps, Cancelled)
+	}
+}
+
+func TestProcessChanContext_Blocked(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	in := make(chan int)
+	out := make(chan int)
+
+	result := ProcessChanContext(ctx, in, out, 0, []int{3, 0, 4, 0, 99}, 0)
+	if result.Status != Cancelled {
+		t.Errorf("incorrect status %v; expected %v", result.Status, Cancelled)
+	}
+
+	if _, ok := <-out; ok {
+		t.Errorf("expected output channel to be closed")
+	}
+}
+
+// countdown loops n times decrementing the value at address 8
+func countdown(n int) []int {
+	return []int{1001, 8, -1, 8, 1005, 8, 0, 99, n}
+}
+
+func TestStep_Allocations(t *testing.T) {
+	for _, noCache := range []bool{false, true} {
+		comp := NewInstruction(0, countdown(1000))
+		comp.NoCache = noCache
+
+		// the first pass through the loop fills the cache
+		step := func() {
+			comp.Step()
+			comp.Exec()
+		}
+		step()
+		step()
+
+		allocs := testing.AllocsPerRun(100, step)
+		if allocs != 0 {
+			t.Errorf("incorrect allocations %v per step with no cache %v; expected %v", allocs, noCache, 0)
+		}
+	}
+}
+
+func benchmarkRun(b *testing.B, noCache bool) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		comp := NewInstruction(0, countdown(1000))
+		comp.NoCache = noCache
+		Run(comp)
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	benchmarkRun(b, false)
+}
+
+func BenchmarkRun_NoCache(b *testing.B) {
+	benchmarkRun(b, true)
+}
+
+func BenchmarkDecodeOp(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		DecodeOp(21101, 0)
+	}
+}
+
+func BenchmarkSplitOp(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		SplitOp(21101)
+	}
+}
@@ -0,0 +1,182 @@
+// Package conformance checks intcode interpreters against a corpus of
+// programs with known results.
+//
+// A corpus is a directory with one subdirectory per case holding:
+//
+//	program.txt  the program, in the format read by intcode.Load
+//	input.txt    values read by input instructions, in the same format; optional
+//	output.txt   every value the program outputs before halting
+//	memory.txt   memory from address zero once the program has halted
+//
+// Addresses past the end of memory.txt are not checked. The corpus in
+// testdata covers every opcode and parameter mode of the standard
+// instruction set.
+package conformance
+
+import (
+	"2019/internal/intcode"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	programFile = "program.txt"
+	inputFile   = "input.txt"
+	outputFile  = "output.txt"
+	memoryFile  = "memory.txt"
+)
+
+// Case is a program and the results expected once it halts
+type Case struct {
+	Name    string
+	Program []int
+	Input   []int
+	Output  []int
+	Memory  []int
+}
+
+// Result is what an interpreter left behind once a program halted. Memory
+// returns the final value at an address.
+type Result struct {
+	Output []int
+	Memory func(address int) (int, error)
+}
+
+// Interpreter runs a copy of program until it halts, reading input in order.
+// It returns an error if the program does not halt.
+type Interpreter interface {
+	Run(program []int, input []int) (*Result, error)
+}
+
+// InterpreterFunc adapts a function to Interpreter
+type InterpreterFunc func(program []int, input []int) (*Result, error)
+
+// Run ...
+func (f InterpreterFunc) Run(program []int, input []int) (*Result, error) {
+	return f(program, input)
+}
+
+// MismatchError reports the first value that differs from the expected
+// results. What is either "output" or "memory"; Index is the position of the
+// output value or the address.
+type MismatchError struct {
+	Case     string
+	What     string
+	Index    int
+	Value    int
+	Expected int
+}
+
+// Error ...
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("%s: incorrect %s %v at %v; expected %v", e.Case, e.What, e.Value, e.Index, e.Expected)
+}
+
+// Load reads every case in dir, ordered by name
+func Load(dir string) ([]Case, error) {
+	// entries are sorted by name
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cases := []Case{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		c, err := LoadCase(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+
+	return cases, nil
+}
+
+// LoadCase reads the case in dir, which it is named after
+func LoadCase(dir string) (Case, error) {
+	c := Case{Name: filepath.Base(dir), Input: []int{}}
+
+	files := []struct {
+		name     string
+		values   *[]int
+		optional bool
+	}{
+		{programFile, &c.Program, false},
+		{inputFile, &c.Input, true},
+		{outputFile, &c.Output, false},
+		{memoryFile, &c.Memory, false},
+	}
+
+	for _, f := range files {
+		values, err := intcode.LoadFile(filepath.Join(dir, f.name))
+		if os.IsNotExist(err) && f.optional {
+			continue
+		}
+		if err != nil {
+			return c, err
+		}
+
+		*f.values = values
+	}
+
+	return c, nil
+}
+
+// Check runs the case on interp and returns a *MismatchError for the first
+// output value or address that differs from the expected results
+func (c Case) Check(interp Interpreter) error {
+	r, err := interp.Run(append([]int{}, c.Program...), append([]int{}, c.Input...))
+	if err != nil {
+		return fmt.Errorf("%s: %s", c.Name, err.Error())
+	}
+
+	for i := 0; i < len(r.Output) && i < len(c.Output); i++ {
+		if r.Output[i] != c.Output[i] {
+			return &MismatchError{Case: c.Name, What: "output", Index: i, Value: r.Output[i], Expected: c.Output[i]}
+		}
+	}
+
+	if len(r.Output) != len(c.Output) {
+		return fmt.Errorf("%s: incorrect output count %v; expected %v", c.Name, len(r.Output), len(c.Output))
+	}
+
+	for address, expected := range c.Memory {
+		v, err := r.Memory(address)
+		if err != nil {
+			return fmt.Errorf("%s: reading address %v: %s", c.Name, address, err.Error())
+		}
+
+		if v != expected {
+			return &MismatchError{Case: c.Name, What: "memory", Index: address, Value: v, Expected: expected}
+		}
+	}
+
+	return nil
+}
+
+// Test checks interp against every case in dir, each in its own subtest
+func Test(t *testing.T, dir string, interp Interpreter) {
+	cases, err := Load(dir)
+	if err != nil {
+		t.Fatalf("cannot load corpus: %s", err.Error())
+	}
+
+	if len(cases) == 0 {
+		t.Fatalf("no cases found in %s", dir)
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			if err := c.Check(interp); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package conformance
+
+import (
+	"2019/internal/intcode"
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const corpus = "testdata"
+
+// instruction runs the program on an Instruction with text input and output
+var instruction = InterpreterFunc(func(program []int, input []int) (*Result, error) {
+	in := &bytes.Buffer{}
+	for _, v := range input {
+		fmt.Fprintln(in, v)
+	}
+
+	out := &bytes.Buffer{}
+	comp := intcode.NewInstruction(0, program)
+	comp.Input = intcode.NewTextReader(in)
+	comp.Output = intcode.NewTextWriter(out)
+
+	result := intcode.Run(comp)
+	if result.Status != intcode.Halted {
+		return nil, fmt.Errorf("stopped with status %v: %v", result.Status, result.Fault)
+	}
+
+	output, err := intcode.Load(strings.NewReader(strings.Replace(out.String(), "\n", ",", -1)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Output: output, Memory: comp.Peek}, nil
+})
+
+// machine runs the program on a Machine fed every input value up front
+var machine = InterpreterFunc(func(program []int, input []int) (*Result, error) {
+	m := intcode.NewMachine(program)
+	m.Feed(input...)
+
+	r := &Result{Output: []int{}, Memory: m.Peek}
+	for {
+		status, err := m.Run()
+		if err != nil {
+			return nil, err
+		}
+
+		switch status {
+		case intcode.HasOutput:
+			v, _ := m.Output()
+			r.Output = append(r.Output, v)
+		case intcode.Halted:
+			return r, nil
+		default:
+			return nil, fmt.Errorf("stopped with status %v", status)
+		}
+	}
+})
+
+// bigMachine runs the program on a BigMachine
+var bigMachine = InterpreterFunc(func(program []int, input []int) (*Result, error) {
+	m := intcode.NewBigMachine(program)
+	for _, v := range input {
+		m.Feed(big.NewInt(int64(v)))
+	}
+
+	r := &Result{Output: []int{}}
+	r.Memory = func(address int) (int, error) {
+		v, err := m.Peek(address)
+		if err != nil {
+			return 0, err
+		}
+
+		return int(v.Int64()), nil
+	}
+
+	for {
+		status, err := m.Run()
+		if err != nil {
+			return nil, err
+		}
+
+		switch status {
+		case intcode.HasOutput:
+			v, _ := m.Output()
+			r.Output = append(r.Output, int(v.Int64()))
+		case intcode.Halted:
+			return r, nil
+		default:
+			return nil, fmt.Errorf("stopped with status %v", status)
+		}
+	}
+})
+
+func TestInstruction(t *testing.T) {
+	Test(t, corpus, instruction)
+}
+
+func TestMachine(t *testing.T) {
+	Test(t, corpus, machine)
+}
+
+func TestBigMachine(t *testing.T) {
+	Test(t, corpus, bigMachine)
+}
+
+func TestLoad(t *testing.T) {
+	cases, err := Load(corpus)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	// every opcode and mode must appear at least once in the corpus
+	ops := map[intcode.OpCode]bool{}
+	modes := map[intcode.ParameterMode]bool{}
+	for _, c := range cases {
+		for _, v := range c.Program {
+			code, m, err := intcode.DecodeOp(v, 0)
+			if err != nil {
+				continue
+			}
+
+			ops[code] = true
+			arity, _ := intcode.Arity(code)
+			for _, mode := range m[:arity] {
+				modes[mode] = true
+			}
+		}
+	}
+
+	for _, code := range []intcode.OpCode{intcode.AddOp, intcode.MultiplyOp, intcode.InputOp, intcode.OutputOp, intcode.JumpTrue, intcode.JumpFalse, intcode.LessThan, intcode.Equals, intcode.RelativeBase, intcode.TerminateOp} {
+		if !ops[code] {
+			t.Errorf("missing opcode %v", code)
+		}
+	}
+
+	for _, mode := range []intcode.ParameterMode{intcode.PositionMode, intcode.ImmediateMode, intcode.RelativeMode} {
+		if !modes[mode] {
+			t.Errorf("missing mode %v", mode)
+		}
+	}
+}
+
+func TestCase_Check(t *testing.T) {
+	c, err := LoadCase(filepath.Join(corpus, "self-modifying-loop"))
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	// drops the last output value
+	short := InterpreterFunc(func(program []int, input []int) (*Result, error) {
+		r, err := machine(program, input)
+		if err == nil {
+			r.Output = r.Output[:len(r.Output)-1]
+		}
+		return r, err
+	})
+
+	// reports the program as loaded
+	unchanged := InterpreterFunc(func(program []int, input []int) (*Result, error) {
+		r, err := machine(program, input)
+		if err == nil {
+			r.Memory = func(address int) (int, error) {
+				return program[address], nil
+			}
+		}
+		return r, err
+	})
+
+	failing := InterpreterFunc(func(program []int, input []int) (*Result, error) {
+		return nil, errors.New("not implemented")
+	})
+
+	if err := c.Check(short); err == nil || !strings.Contains(err.Error(), "output count 2") {
+		t.Errorf("incorrect error %v for missing output", err)
+	}
+
+	expected := &MismatchError{Case: "self-modifying-loop", What: "memory", Index: 1, Value: 0, Expected: 3}
+	if m, ok := c.Check(unchanged).(*MismatchError); !ok || *m != *expected {
+		t.Errorf("incorrect error %v; expected %v", m, expected)
+	}
+
+	if err := c.Check(failing); err == nil {
+		t.Errorf("expected error from failing interpreter")
+	}
+}
+
+func TestLoadCase_Missing(t *testing.T) {
+	dir, err := os.MkdirTemp("", "conformance")
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	// input is optional, but output and memory are not
+	os.WriteFile(filepath.Join(dir, "program.txt"), []byte("99\n"), 0644)
+	if _, err := LoadCase(dir); err == nil {
+		t.Errorf("expected error for case without output")
+	}
+
+	os.WriteFile(filepath.Join(dir, "output.txt"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(dir, "memory.txt"), []byte("99\n"), 0644)
+	c, err := LoadCase(dir)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	if err := c.Check(machine); err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+	}
+}
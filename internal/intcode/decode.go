@@ -0,0 +1,85 @@
+package intcode
+
+import (
+	"fmt"
+)
+
+// modeCount is the number of parameter mode digits decoded for every opcode
+const modeCount = 4
+
+// DecodeError ...
+type DecodeError struct {
+	Value   int
+	Address int
+	// Digit is the offending decimal digit counted from the right starting at
+	// zero; errors in the opcode itself report digit 0
+	Digit  int
+	Reason string
+}
+
+// Error ...
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("cannot decode %v at address %v; digit %v: %s", e.Value, e.Address, e.Digit, e.Reason)
+}
+
+// Arity returns the number of parameters taken by an opcode in DefaultOps
+func Arity(code OpCode) (int, bool) {
+	return DefaultOps.Arity(code)
+}
+
+// writesTo reports whether the parameter at index is the address an opcode
+// stores its result in
+func writesTo(code OpCode, index int) bool {
+	return DefaultOps.writesTo(code, index)
+}
+
+// DecodeOp splits an instruction value found at address into its opcode and
+// parameter modes using DefaultOps
+func DecodeOp(value int, address int) (OpCode, []ParameterMode, error) {
+	return DefaultOps.Decode(value, address)
+}
+
+// Decode splits an instruction value found at address into its opcode and
+// parameter modes
+func (t *OpTable) Decode(value int, address int) (OpCode, []ParameterMode, error) {
+	modes := make([]ParameterMode, modeCount)
+	code, err := t.decode(value, address, modes)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return code, modes, nil
+}
+
+// decode is Decode storing the modes in a buffer of modeCount values
+func (t *OpTable) decode(value int, address int, modes []ParameterMode) (OpCode, error) {
+	if value < 0 {
+		return 0, &DecodeError{Value: value, Address: address, Reason: "negative instruction"}
+	}
+
+	code := OpCode(value % 100)
+	if _, ok := t.ops[code]; !ok {
+		m := fmt.Sprintf("unknown opcode %v", int(code))
+		return 0, &DecodeError{Value: value, Address: address, Reason: m}
+	}
+
+	rest := value / 100
+	for i := 0; i < modeCount; i++ {
+		digit := rest % 10
+		rest = rest / 10
+
+		switch ParameterMode(digit) {
+		case PositionMode, ImmediateMode, RelativeMode:
+			modes[i] = ParameterMode(digit)
+		default:
+			m := fmt.Sprintf("unknown parameter mode %v", digit)
+			return 0, &DecodeError{Value: value, Address: address, Digit: i + 2, Reason: m}
+		}
+	}
+
+	if rest != 0 {
+		return 0, &DecodeError{Value: value, Address: address, Digit: modeCount + 2, Reason: "too many digits"}
+	}
+
+	return code, nil
+}
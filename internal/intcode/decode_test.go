@@ -0,0 +1,72 @@
+package intcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecodeOp(t *testing.T) {
+	inputs := []int{1002, 21101, 99, 98, 1301, 90002, -1, 1111102}
+	expectedOps := []OpCode{MultiplyOp, AddOp, TerminateOp, 0, 0, 0, 0, 0}
+	expectedErr := []*DecodeError{
+		nil,
+		nil,
+		nil,
+		&DecodeError{Value: 98, Address: 7, Digit: 0},
+		&DecodeError{Value: 1301, Address: 7, Digit: 2},
+		&DecodeError{Value: 90002, Address: 7, Digit: 4},
+		&DecodeError{Value: -1, Address: 7, Digit: 0},
+		&DecodeError{Value: 1111102, Address: 7, Digit: 6}}
+
+	for i, input := range inputs {
+		code, _, err := DecodeOp(input, 7)
+
+		if expectedErr[i] == nil {
+			if err != nil {
+				t.Errorf("unexpected error returned: %s", err.Error())
+			}
+
+			if code != expectedOps[i] {
+				t.Errorf("incorrect op code %v for input %v; expected %v", code, input, expectedOps[i])
+			}
+			continue
+		}
+
+		de, ok := err.(*DecodeError)
+		if !ok {
+			t.Errorf("expected decode error for input %v; got %v", input, err)
+			continue
+		}
+
+		if de.Value != expectedErr[i].Value || de.Address != expectedErr[i].Address || de.Digit != expectedErr[i].Digit {
+			t.Errorf("incorrect decode error %+v for input %v; expected %+v", *de, input, *expectedErr[i])
+		}
+	}
+}
+
+func TestSplitOp_Invalid(t *testing.T) {
+	code, modes := SplitOp(1398)
+
+	if code != OpCode(98) {
+		t.Errorf("incorrect op code %v; expected %v", code, 98)
+	}
+
+	if len(modes) != modeCount {
+		t.Errorf("incorrect parameter length %v; expected %v", len(modes), modeCount)
+	}
+}
+
+func TestProcess_DecodeFailure(t *testing.T) {
+	set := []int{1101, 1, 1, 5, 42, 0}
+
+	result := Process(strings.NewReader(""), bytes.NewBuffer([]byte{}), 0, set)
+	if result.Status != Failed {
+		t.Errorf("incorrect status %v; expected %v", result.Status, Failed)
+		return
+	}
+
+	if result.Fault.Position != 4 || result.Fault.Op != 42 {
+		t.Errorf("incorrect fault %+v; expected position 4 op 42", *result.Fault)
+	}
+}
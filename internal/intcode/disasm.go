@@ -0,0 +1,130 @@
+package intcode
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DataDirective marks a value that does not decode as an instruction
+const DataDirective = ".data"
+
+// Mnemonic returns the name of an opcode in DefaultOps
+func Mnemonic(code OpCode) (string, bool) {
+	return DefaultOps.Mnemonic(code)
+}
+
+// ParseMnemonic returns the opcode for a mnemonic produced by Mnemonic
+func ParseMnemonic(name string) (OpCode, bool) {
+	return DefaultOps.ParseMnemonic(name)
+}
+
+// Line is a single disassembled instruction or data value
+type Line struct {
+	Address  int
+	Values   []int
+	Mnemonic string
+	Operands []string
+}
+
+// String ...
+func (l Line) String() string {
+	text := fmt.Sprintf("%6v  %-5s %s", l.Address, l.Mnemonic, strings.Join(l.Operands, ", "))
+	return strings.TrimRight(text, " ")
+}
+
+// FormatOperand renders a parameter in the notation used by the assembler
+func FormatOperand(value int, mode ParameterMode) string {
+	switch mode {
+	case ImmediateMode:
+		return fmt.Sprintf("#%v", value)
+	case RelativeMode:
+		if value < 0 {
+			return fmt.Sprintf("[rb%v]", value)
+		}
+		return fmt.Sprintf("[rb+%v]", value)
+	}
+
+	return fmt.Sprintf("[%v]", value)
+}
+
+// Disassemble walks the program from address zero using DefaultOps
+func Disassemble(set []int) []Line {
+	return DefaultOps.Disassemble(set)
+}
+
+// DisassembleAt decodes the instruction at pos using DefaultOps
+func DisassembleAt(pos int, set []int) (Line, bool) {
+	return DefaultOps.DisassembleAt(pos, set)
+}
+
+// Disassemble walks the program from address zero. Values that do not decode
+// as an instruction, or whose parameters run past the end of the program, are
+// emitted as data.
+func (t *OpTable) Disassemble(set []int) []Line {
+	lines := []Line{}
+
+	for pos := 0; pos < len(set); {
+		line, ok := t.DisassembleAt(pos, set)
+		if !ok {
+			line = Line{
+				Address:  pos,
+				Values:   set[pos : pos+1],
+				Mnemonic: DataDirective,
+				Operands: []string{fmt.Sprintf("%v", set[pos])}}
+		}
+
+		lines = append(lines, line)
+		pos += len(line.Values)
+	}
+
+	return lines
+}
+
+// DisassembleAt decodes the instruction at pos. It returns false when the
+// value does not decode or its parameters run past the end of the program.
+func (t *OpTable) DisassembleAt(pos int, set []int) (Line, bool) {
+	if pos < 0 || pos >= len(set) {
+		return Line{}, false
+	}
+
+	code, modes, err := t.Decode(set[pos], pos)
+	if err != nil {
+		return Line{}, false
+	}
+
+	quantity, _ := t.Arity(code)
+	if pos+quantity >= len(set) {
+		return Line{}, false
+	}
+
+	params := GetParameters(quantity, pos+1, set)
+	operands := make([]string, len(params))
+	for i, p := range params {
+		operands[i] = FormatOperand(p.Value, modes[i])
+	}
+
+	m, _ := t.Mnemonic(code)
+	return Line{
+		Address:  pos,
+		Values:   set[pos : pos+quantity+1],
+		Mnemonic: m,
+		Operands: operands}, true
+}
+
+// WriteDisassembly writes one line per instruction using DefaultOps
+func WriteDisassembly(w io.Writer, set []int) error {
+	return DefaultOps.WriteDisassembly(w, set)
+}
+
+// WriteDisassembly writes one line per instruction
+func (t *OpTable) WriteDisassembly(w io.Writer, set []int) error {
+	for _, line := range t.Disassemble(set) {
+		_, err := fmt.Fprintln(w, line.String())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
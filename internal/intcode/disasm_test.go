@@ -0,0 +1,63 @@
+package intcode
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	set := []int{109, -1, 1002, 3, 10, 7, 21101, 1, 2, 0, 204, 3, 1105, 1, 0, 99, 98, 1}
+	expected := []string{
+		"     0  arb   #-1",
+		"     2  mul   [3], #10, [7]",
+		"     6  add   #1, #2, [rb+0]",
+		"    10  out   [rb+3]",
+		"    12  jt    #1, #0",
+		"    15  hlt",
+		"    16  .data 98",
+		"    17  .data 1"}
+
+	lines := Disassemble(set)
+	if len(lines) != len(expected) {
+		t.Errorf("incorrect number of lines %v; expected %v", len(lines), len(expected))
+		return
+	}
+
+	for i, line := range lines {
+		if line.String() != expected[i] {
+			t.Errorf("incorrect line %q; expected %q", line.String(), expected[i])
+		}
+	}
+}
+
+func TestDisassemble_Truncated(t *testing.T) {
+	set := []int{1, 0, 0}
+	expected := []string{DataDirective, DataDirective, DataDirective}
+
+	lines := Disassemble(set)
+	if len(lines) != len(expected) {
+		t.Errorf("incorrect number of lines %v; expected %v", len(lines), len(expected))
+		return
+	}
+
+	for i, line := range lines {
+		if line.Mnemonic != expected[i] {
+			t.Errorf("incorrect mnemonic %v; expected %v", line.Mnemonic, expected[i])
+		}
+	}
+}
+
+func TestWriteDisassembly(t *testing.T) {
+	set := []int{3, 0, 4, 0, 99}
+	expected := "     0  in    [0]\n     2  out   [0]\n     4  hlt\n"
+
+	buf := new(bytes.Buffer)
+	err := WriteDisassembly(buf, set)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+	}
+
+	if buf.String() != expected {
+		t.Errorf("incorrect disassembly %q; expected %q", buf.String(), expected)
+	}
+}
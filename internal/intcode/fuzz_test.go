@@ -0,0 +1,233 @@
+package intcode
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const (
+	// fuzzMaxAddress bounds the memory of fuzzed programs
+	fuzzMaxAddress = 1 << 12
+	// fuzzBudget bounds the instructions executed by fuzzed programs
+	fuzzBudget = 1 << 12
+	// fuzzMaxCodes bounds the length of fuzzed programs
+	fuzzMaxCodes = 1 << 10
+)
+
+// fuzzCodes decodes a program from varints so short inputs can still hold
+// large values
+func fuzzCodes(data []byte) []int {
+	codes := []int{}
+	for len(data) > 0 && len(codes) < fuzzMaxCodes {
+		v, n := binary.Varint(data)
+		if n <= 0 {
+			break
+		}
+
+		codes = append(codes, int(v))
+		data = data[n:]
+	}
+
+	return codes
+}
+
+// fuzzBytes encodes a program for fuzzCodes
+func fuzzBytes(codes []int) []byte {
+	data := []byte{}
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, c := range codes {
+		n := binary.PutVarint(buf, int64(c))
+		data = append(data, buf[:n]...)
+	}
+
+	return data
+}
+
+func FuzzSplitOp(f *testing.F) {
+	for _, v := range []int{1, 99, 1002, 21101, 203, 0, -1, 12345678, 34501, math.MaxInt64, math.MinInt64} {
+		f.Add(int64(v))
+	}
+
+	f.Fuzz(func(t *testing.T, v int64) {
+		code, modes := SplitOp(int(v))
+		if len(modes) != modeCount {
+			t.Fatalf("incorrect mode count %v for %v; expected %v", len(modes), v, modeCount)
+		}
+
+		for _, m := range modes {
+			if m != PositionMode && m != ImmediateMode && m != RelativeMode {
+				t.Fatalf("incorrect mode %v for %v", m, v)
+			}
+		}
+
+		if code != OpCode(int(v)%100) {
+			t.Fatalf("incorrect code %v for %v; expected %v", code, v, int(v)%100)
+		}
+
+		decoded, decodedModes, err := DecodeOp(int(v), 0)
+		if err != nil {
+			return
+		}
+
+		if decoded != code {
+			t.Fatalf("incorrect code %v for %v; DecodeOp returned %v", code, v, decoded)
+		}
+
+		for i := range modes {
+			if modes[i] != decodedModes[i] {
+				t.Fatalf("incorrect modes %v for %v; DecodeOp returned %v", modes, v, decodedModes)
+			}
+		}
+	})
+}
+
+func FuzzGetParameters(f *testing.F) {
+	f.Add(3, 0, fuzzBytes([]int{1, 0, 0, 0, 99}))
+	f.Add(2, 3, fuzzBytes([]int{1, 0, 0}))
+	f.Add(-1, -5, []byte{})
+	f.Add(1, math.MaxInt64, fuzzBytes([]int{4}))
+
+	f.Fuzz(func(t *testing.T, quantity int, position int, data []byte) {
+		if quantity > fuzzMaxCodes {
+			quantity = quantity % fuzzMaxCodes
+		}
+
+		set := fuzzCodes(data)
+		params := GetParameters(quantity, position, set)
+
+		expected := quantity
+		if expected < 0 {
+			expected = 0
+		}
+		if len(params) != expected {
+			t.Fatalf("incorrect parameter count %v; expected %v", len(params), expected)
+		}
+
+		for j, p := range params {
+			if p.Position != position+j {
+				t.Fatalf("incorrect position %v for parameter %v; expected %v", p.Position, j, position+j)
+			}
+
+			value := 0
+			if p.Position >= 0 && p.Position < len(set) {
+				value = set[p.Position]
+			}
+			if p.Value != value {
+				t.Fatalf("incorrect value %v for parameter %v; expected %v", p.Value, j, value)
+			}
+		}
+	})
+}
+
+func FuzzRun(f *testing.F) {
+	f.Add(fuzzBytes([]int{3, 9, 8, 9, 10, 9, 4, 9, 99, -1, 8}), int64(8))
+	f.Add(fuzzBytes([]int{3, 3, 1107, -1, 8, 3, 4, 3, 99}), int64(5))
+	f.Add(fuzzBytes([]int{109, 1, 204, -1, 1001, 100, 1, 100, 1008, 100, 16, 101, 1006, 101, 0, 99}), int64(0))
+	f.Add(fuzzBytes([]int{104, 1125899906842624, 99}), int64(0))
+	f.Add(fuzzBytes([]int{1105, 1, 0}), int64(0))
+	f.Add(fuzzBytes([]int{21101, 1, 1, 4095, 1101, 0, 4096, 0, 99}), int64(0))
+
+	f.Fuzz(func(t *testing.T, data []byte, input int64) {
+		program := fuzzCodes(data)
+
+		comp := NewInstruction(0, append([]int{}, program...))
+		comp.Memory = NewMemory(fuzzMaxAddress)
+		comp.Input = &sliceInput{values: []int{int(input), int(input)}}
+		comp.Output = &stepRecorder{}
+		result := RunContext(context.Background(), comp, fuzzBudget)
+
+		if result.Steps > fuzzBudget {
+			t.Fatalf("incorrect step count %v; expected at most %v", result.Steps, fuzzBudget)
+		}
+
+		if pages := comp.Memory.Pages(); pages > fuzzMaxAddress/pageSize+1 {
+			t.Fatalf("incorrect page count %v; expected at most %v", pages, fuzzMaxAddress/pageSize+1)
+		}
+
+		if result.Status == Failed && result.Fault == nil {
+			t.Fatalf("missing fault for failed run")
+		}
+
+		if d := differ(nil, program, []int{int(input), int(input)}, fuzzMaxAddress, fuzzBudget); d != nil {
+			t.Fatalf("interpreters diverged on %v: %s", program, d)
+		}
+	})
+}
+
+// randomProgram returns a program of mostly valid instructions whose
+// parameters point near the program so that it reads, writes and jumps into
+// its own code
+func randomProgram(r *rand.Rand) []int {
+	ops := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 99}
+	arity := map[int]int{1: 3, 2: 3, 3: 1, 4: 1, 5: 2, 6: 2, 7: 3, 8: 3, 9: 1, 99: 0}
+
+	program := []int{}
+	for n := 5 + r.Intn(25); n > 0; n-- {
+		if r.Intn(20) == 0 {
+			program = append(program, r.Intn(30000)-100)
+			continue
+		}
+
+		op := ops[r.Intn(len(ops))]
+		value := op
+		for k, scale := 0, 100; k < arity[op]; k, scale = k+1, scale*10 {
+			value += r.Intn(3) * scale
+		}
+
+		program = append(program, value)
+		for k := 0; k < arity[op]; k++ {
+			program = append(program, r.Intn(80)-5)
+		}
+	}
+
+	return program
+}
+
+func TestDifferential(t *testing.T) {
+	r := rand.New(rand.NewSource(2019))
+
+	for n := 0; n < 2000; n++ {
+		program := randomProgram(r)
+		input := []int{}
+		for k := r.Intn(4); k > 0; k-- {
+			input = append(input, r.Intn(100)-10)
+		}
+
+		if d := differ(nil, program, input, 100, 500); d != nil {
+			t.Errorf("interpreters diverged on %v with input %v: %s", program, input, d)
+			return
+		}
+	}
+}
+
+func TestDifferential_Divergence(t *testing.T) {
+	// a table whose add subtracts
+	ops := NewOpTable()
+	for _, info := range standardOps {
+		if info.Code == AddOp {
+			info.Handler = func(i *Instruction) error {
+				a, _ := i.Value(0)
+				b, _ := i.Value(1)
+				return i.SetValue(2, a-b)
+			}
+		}
+		if err := ops.Register(info); err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			return
+		}
+	}
+
+	program := []int{1101, 2, 0, 9, 1101, 2, 1, 9, 99, 0}
+	d := differ(ops, program, nil, 100, 100)
+	if d == nil {
+		t.Errorf("expected divergence")
+		return
+	}
+
+	if d.Step != 1 || d.Got.Position != 4 || d.Got.Writes[0].Value != 1 || d.Expected.Writes[0].Value != 3 {
+		t.Errorf("incorrect divergence %s; expected step 1 at ip 4", d)
+	}
+}
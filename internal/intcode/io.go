@@ -0,0 +1,111 @@
+package intcode
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ValueReader ...
+type ValueReader interface {
+	ReadValue() (int, error)
+}
+
+// ValueWriter ...
+type ValueWriter interface {
+	WriteValue(value int) error
+}
+
+// ChanReader ...
+type ChanReader struct {
+	ch  <-chan int
+	ctx context.Context
+}
+
+// NewChanReader ...
+func NewChanReader(ch <-chan int) *ChanReader {
+	return &ChanReader{ch: ch, ctx: context.Background()}
+}
+
+// ReadValue returns io.EOF once the channel is closed and drained
+func (r *ChanReader) ReadValue() (int, error) {
+	select {
+	case v, ok := <-r.ch:
+		if !ok {
+			return 0, io.EOF
+		}
+		return v, nil
+	case <-r.ctx.Done():
+		return 0, r.ctx.Err()
+	}
+}
+
+// ChanWriter ...
+type ChanWriter struct {
+	ch  chan<- int
+	ctx context.Context
+}
+
+// NewChanWriter ...
+func NewChanWriter(ch chan<- int) *ChanWriter {
+	return &ChanWriter{ch: ch, ctx: context.Background()}
+}
+
+// WriteValue ...
+func (w *ChanWriter) WriteValue(value int) error {
+	select {
+	case w.ch <- value:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+// TextReader reads newline delimited decimal values
+type TextReader struct {
+	reader *bufio.Reader
+}
+
+// NewTextReader ...
+func NewTextReader(r io.Reader) *TextReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &TextReader{reader: br}
+}
+
+// ReadValue reads a single line and parses it as a decimal value. A last
+// line without a trailing newline is still read.
+func (r *TextReader) ReadValue() (int, error) {
+	text, err := r.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || text == "") {
+		return 0, err
+	}
+
+	t := strings.TrimRightFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r)
+	})
+	return strconv.Atoi(t)
+}
+
+// TextWriter writes values as newline delimited decimal text
+type TextWriter struct {
+	writer io.Writer
+}
+
+// NewTextWriter ...
+func NewTextWriter(w io.Writer) *TextWriter {
+	return &TextWriter{writer: w}
+}
+
+// WriteValue ...
+func (w *TextWriter) WriteValue(value int) error {
+	_, err := fmt.Fprintf(w.writer, "%v\n", value)
+	return err
+}
@@ -0,0 +1,147 @@
+package intcode
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Stdin is the path LoadFile reads standard input for
+const Stdin = "-"
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// LoadError reports a value that could not be parsed. Line and Column count
+// from one; Column is in bytes.
+type LoadError struct {
+	Line   int
+	Column int
+	Token  string
+	Reason string
+}
+
+// Error ...
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("line %v, column %v: %s %q", e.Line, e.Column, e.Reason, e.Token)
+}
+
+// Load reads a program of comma separated values. Values may be surrounded by
+// whitespace, split across lines after a comma and followed by a trailing
+// comma. An empty value between two commas and two values without a comma
+// between them are errors. Gzip compressed input is detected and
+// decompressed.
+func Load(r io.Reader) ([]int, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(gzipMagic))
+	if string(magic) == string(gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		br = bufio.NewReader(gz)
+	}
+
+	l := &loader{codes: []int{}, empty: true}
+	for number := 1; ; number++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		perr := l.parse(line, number)
+		if perr != nil {
+			return nil, perr
+		}
+
+		if err == io.EOF {
+			return l.codes, nil
+		}
+	}
+}
+
+type loader struct {
+	codes []int
+	// empty is true until a value follows the last comma
+	empty bool
+}
+
+// parse splits a line on commas, skipping whitespace around values
+func (l *loader) parse(line string, number int) error {
+	start := -1
+	for col := 0; col <= len(line); col++ {
+		c := byte(' ')
+		if col < len(line) {
+			c = line[col]
+		}
+
+		switch c {
+		case ',', ' ', '\t', '\r', '\n':
+			if start >= 0 {
+				err := l.value(line[start:col], number, start+1)
+				if err != nil {
+					return err
+				}
+				start = -1
+			}
+
+			if c == ',' {
+				if l.empty {
+					return &LoadError{Line: number, Column: col + 1, Token: ",", Reason: "missing value before"}
+				}
+				l.empty = true
+			}
+		default:
+			if start < 0 {
+				start = col
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *loader) value(token string, line int, column int) error {
+	if !l.empty {
+		return &LoadError{Line: line, Column: column, Token: token, Reason: "missing comma before"}
+	}
+
+	v, err := strconv.Atoi(token)
+	if err != nil {
+		reason := "invalid value"
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			reason = "value out of range"
+		}
+		return &LoadError{Line: line, Column: column, Token: token, Reason: reason}
+	}
+
+	l.codes = append(l.codes, v)
+	l.empty = false
+	return nil
+}
+
+// LoadFile reads a program from path, or from standard input when path is
+// Stdin
+func LoadFile(path string) ([]int, error) {
+	if path == Stdin {
+		return Load(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	codes, err := Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	return codes, nil
+}
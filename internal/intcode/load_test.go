@@ -0,0 +1,140 @@
+package intcode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	inputs := []string{
+		"1,2,3",
+		"1,2,3\n",
+		" 1 , 2,\t3 ,\n",
+		"1,2,\n3,\n",
+		"1,-2,\r\n3\r\n",
+		"",
+		"\n\n"}
+
+	expected := [][]int{
+		[]int{1, 2, 3},
+		[]int{1, 2, 3},
+		[]int{1, 2, 3},
+		[]int{1, 2, 3},
+		[]int{1, -2, 3},
+		[]int{},
+		[]int{}}
+
+	for i, input := range inputs {
+		codes, err := Load(strings.NewReader(input))
+		if err != nil {
+			t.Errorf("unexpected error returned for test %v: %s", i+1, err.Error())
+			continue
+		}
+
+		if !equalCodes(codes, expected[i]) {
+			t.Errorf("incorrect codes %v for test %v; expected %v", codes, i+1, expected[i])
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	inputs := []string{
+		"1,2,x",
+		"1,2,\n3,4a,5",
+		"1,,2",
+		",1",
+		"1,\n,2",
+		"1,99999999999999999999999",
+		"1 2",
+		"1,2\n3",
+		"1,\t2 3,4"}
+
+	expected := []LoadError{
+		LoadError{Line: 1, Column: 5, Token: "x"},
+		LoadError{Line: 2, Column: 3, Token: "4a"},
+		LoadError{Line: 1, Column: 3, Token: ","},
+		LoadError{Line: 1, Column: 1, Token: ","},
+		LoadError{Line: 2, Column: 1, Token: ","},
+		LoadError{Line: 1, Column: 3, Token: "99999999999999999999999"},
+		LoadError{Line: 1, Column: 3, Token: "2"},
+		LoadError{Line: 2, Column: 1, Token: "3"},
+		LoadError{Line: 1, Column: 6, Token: "3"}}
+
+	for i, input := range inputs {
+		_, err := Load(strings.NewReader(input))
+		le, ok := err.(*LoadError)
+		if !ok {
+			t.Errorf("incorrect error %v for test %v; expected a *LoadError", err, i+1)
+			continue
+		}
+
+		if le.Line != expected[i].Line || le.Column != expected[i].Column || le.Token != expected[i].Token {
+			t.Errorf("incorrect error %v for test %v; expected line %v, column %v, token %q", le, i+1, expected[i].Line, expected[i].Column, expected[i].Token)
+		}
+	}
+}
+
+func TestLoad_Gzip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	gz.Write([]byte("104,7,99\n"))
+	gz.Close()
+
+	codes, err := Load(buf)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	expected := []int{104, 7, 99}
+	if !equalCodes(codes, expected) {
+		t.Errorf("incorrect codes %v; expected %v", codes, expected)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := os.MkdirTemp("", "intcode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "codes.txt")
+	os.WriteFile(path, []byte("1,0,0,0,99\n"), 0644)
+
+	codes, err := LoadFile(path)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+	} else if !equalCodes(codes, []int{1, 0, 0, 0, 99}) {
+		t.Errorf("incorrect codes %v; expected %v", codes, []int{1, 0, 0, 0, 99})
+	}
+
+	os.WriteFile(path, []byte("1,0,zero\n"), 0644)
+	_, err = LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "line 1, column 5") {
+		t.Errorf("incorrect error %v; expected the position of the bad value", err)
+	}
+
+	_, err = LoadFile(filepath.Join(dir, "missing.txt"))
+	if err == nil {
+		t.Errorf("expected error loading a missing file")
+	}
+}
+
+func equalCodes(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
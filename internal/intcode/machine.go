@@ -0,0 +1,248 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Status ...
+type Status int
+
+const (
+	// NeedsInput ...
+	NeedsInput Status = 1
+	// HasOutput ...
+	HasOutput Status = 2
+	// Halted ...
+	Halted Status = 3
+	// Failed ...
+	Failed Status = 4
+	// Cancelled ...
+	Cancelled Status = 5
+	// BudgetExhausted ...
+	BudgetExhausted Status = 6
+)
+
+// ErrBudgetExhausted is returned once a machine has executed its instruction
+// budget
+var ErrBudgetExhausted = errors.New("instruction budget exhausted")
+
+// String ...
+func (s Status) String() string {
+	switch s {
+	case NeedsInput:
+		return "needs input"
+	case HasOutput:
+		return "has output"
+	case Halted:
+		return "halted"
+	case Failed:
+		return "failed"
+	case Cancelled:
+		return "cancelled"
+	case BudgetExhausted:
+		return "budget exhausted"
+	}
+
+	return "unknown"
+}
+
+// valueQueue buffers values between a machine and its caller
+type valueQueue struct {
+	values []int
+}
+
+// ReadValue returns io.EOF when the queue is empty
+func (q *valueQueue) ReadValue() (int, error) {
+	if len(q.values) == 0 {
+		return 0, io.EOF
+	}
+
+	v := q.values[0]
+	q.values = q.values[1:]
+	return v, nil
+}
+
+// WriteValue ...
+func (q *valueQueue) WriteValue(value int) error {
+	q.values = append(q.values, value)
+	return nil
+}
+
+// Machine runs a program cooperatively. Each call to Run executes
+// instructions until the program needs input that has not been fed, produces
+// an output value or halts.
+type Machine struct {
+	inst    *Instruction
+	input   *valueQueue
+	output  *valueQueue
+	decoded bool
+	halted  bool
+	fault   *Fault
+	budget  int
+}
+
+// NewMachine creates a machine with its own copy of the program
+func NewMachine(set []int) *Machine {
+	data := make([]int, len(set))
+	copy(data, set)
+
+	m := &Machine{
+		inst:   newInstructionSet(0, data),
+		input:  &valueQueue{},
+		output: &valueQueue{}}
+
+	m.inst.Input = m.input
+	m.inst.Output = m.output
+
+	return m
+}
+
+// SetMaxAddress limits the highest address the program may access
+func (m *Machine) SetMaxAddress(max int) {
+	m.inst.memory().MaxAddress = max
+}
+
+// SetTracer reports every executed instruction to t
+func (m *Machine) SetTracer(t Tracer) {
+	m.inst.Tracer = t
+}
+
+// SetOpTable replaces the instruction set used to decode and execute the
+// program
+func (m *Machine) SetOpTable(t *OpTable) {
+	m.inst.Ops = t
+}
+
+// Fork returns an independent copy of the machine, including its pending
+// input and output and its budget. Memory is shared between the two and copied a page at a
+// time as either machine writes to it.
+func (m *Machine) Fork() *Machine {
+	m.inst.pageProgram()
+
+	f := &Machine{
+		input:   &valueQueue{values: append([]int{}, m.input.values...)},
+		output:  &valueQueue{values: append([]int{}, m.output.values...)},
+		decoded: m.decoded,
+		halted:  m.halted,
+		fault:   m.fault,
+		budget:  m.budget}
+
+	inst := *m.inst
+	inst.Memory = m.inst.memory().fork()
+	if m.inst.cache != nil {
+		inst.cache = m.inst.cache.fork()
+	}
+	inst.Input = f.input
+	inst.Output = f.output
+	f.inst = &inst
+
+	// the decoded instruction may refer to the buffers of the original
+	inst.Modes = append([]ParameterMode{}, m.inst.Modes...)
+	inst.Parameters = append([]Parameter{}, m.inst.Parameters...)
+
+	return f
+}
+
+// SetBudget limits the total number of instructions the machine executes; a
+// budget of zero is unlimited
+func (m *Machine) SetBudget(budget int) {
+	m.budget = budget
+}
+
+// Steps returns the number of completed instructions, including the one the
+// machine halted at
+func (m *Machine) Steps() int {
+	return m.inst.executed
+}
+
+// Feed queues values to be read by input instructions
+func (m *Machine) Feed(values ...int) {
+	m.input.values = append(m.input.values, values...)
+}
+
+// Output removes and returns the oldest output value
+func (m *Machine) Output() (int, bool) {
+	v, err := m.output.ReadValue()
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
+
+// Peek returns the value at address without changing memory
+func (m *Machine) Peek(address int) (int, error) {
+	return m.inst.read(address)
+}
+
+// Run returns Failed together with a *Fault when an instruction cannot be
+// executed. A failed machine keeps returning the same fault.
+func (m *Machine) Run() (Status, error) {
+	return m.RunContext(context.Background())
+}
+
+// RunContext is Run that also stops with Cancelled when ctx is done, or with
+// BudgetExhausted when the budget is used up. Both are checked between
+// instructions so the machine can be resumed afterwards.
+func (m *Machine) RunContext(ctx context.Context) (Status, error) {
+	done := ctx.Done()
+
+	if m.halted {
+		return Halted, nil
+	}
+
+	if m.fault != nil {
+		return Failed, m.fault
+	}
+
+	for {
+		if done != nil {
+			select {
+			case <-done:
+				return Cancelled, ctx.Err()
+			default:
+			}
+		}
+
+		if !m.decoded && m.budget > 0 && m.inst.executed >= m.budget {
+			return BudgetExhausted, ErrBudgetExhausted
+		}
+
+		// an instruction stays decoded while the machine waits for input so
+		// the next call resumes at the same instruction
+		if !m.decoded {
+			err := m.inst.Step()
+			if err != nil {
+				return m.fail(err)
+			}
+			m.decoded = true
+		}
+
+		op := m.inst.info
+		if op.ReadsInput && len(m.input.values) == 0 {
+			return NeedsInput, nil
+		}
+
+		err := m.inst.Exec()
+		if op.Halts && (err == nil || terminated(err)) {
+			m.halted = true
+			return Halted, nil
+		}
+
+		if err != nil {
+			return m.fail(err)
+		}
+		m.decoded = false
+
+		if op.WritesOutput {
+			return HasOutput, nil
+		}
+	}
+}
+
+func (m *Machine) fail(err error) (Status, error) {
+	m.fault = newFault(m.inst, err)
+	return Failed, m.fault
+}
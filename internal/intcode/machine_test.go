@@ -0,0 +1,312 @@
+package intcode
+
+import (
+	"context"
+	"math/big"
+	"testing"
+)
+
+func TestMachineRun(t *testing.T) {
+	m := NewMachine([]int{3, 0, 4, 0, 99})
+	expected := []Status{NeedsInput, HasOutput, Halted, Halted}
+
+	for i, e := range expected {
+		status, err := m.Run()
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			return
+		}
+
+		if status != e {
+			t.Errorf("incorrect status %v for run %v; expected %v", status, i+1, e)
+		}
+
+		switch status {
+		case NeedsInput:
+			m.Feed(42)
+		case HasOutput:
+			v, ok := m.Output()
+			if !ok || v != 42 {
+				t.Errorf("incorrect output %v; expected %v", v, 42)
+			}
+		}
+	}
+}
+
+func TestMachineRun_CustomOps(t *testing.T) {
+	ops := NewOpTable()
+	infos := []OpInfo{
+		OpInfo{Code: 1, Mnemonic: "get", Arity: 1, Writes: []int{0}, ReadsInput: true, Handler: func(i *Instruction) error {
+			v, err := i.ReadInput()
+			if err != nil {
+				return err
+			}
+			return i.SetValue(0, v)
+		}},
+		OpInfo{Code: 2, Mnemonic: "put", Arity: 1, WritesOutput: true, Handler: func(i *Instruction) error {
+			v, err := i.Value(0)
+			if err != nil {
+				return err
+			}
+			return i.WriteOutput(v * 2)
+		}},
+		OpInfo{Code: 3, Mnemonic: "end", Halts: true, Handler: func(i *Instruction) error {
+			return nil
+		}}}
+
+	for _, info := range infos {
+		if err := ops.Register(info); err != nil {
+			t.Fatalf("unexpected error returned: %s", err.Error())
+		}
+	}
+
+	m := NewMachine([]int{1, 5, 2, 5, 3, 0})
+	m.SetOpTable(ops)
+	expected := []Status{NeedsInput, HasOutput, Halted}
+
+	for i, e := range expected {
+		status, err := m.Run()
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			return
+		}
+
+		if status != e {
+			t.Errorf("incorrect status %v for run %v; expected %v", status, i+1, e)
+		}
+
+		switch status {
+		case NeedsInput:
+			m.Feed(21)
+		case HasOutput:
+			v, ok := m.Output()
+			if !ok || v != 42 {
+				t.Errorf("incorrect output %v; expected %v", v, 42)
+			}
+		}
+	}
+}
+
+func TestMachineRun_CopiesProgram(t *testing.T) {
+	set := []int{1101, 1, 1, 0, 99}
+
+	m := NewMachine(set)
+	m.Run()
+
+	if set[0] != 1101 {
+		t.Errorf("incorrect program value %v; expected %v", set[0], 1101)
+	}
+}
+
+func TestMachineRun_Feedback(t *testing.T) {
+	codes := []int{3, 26, 1001, 26, -4, 26, 3, 27, 1002, 27, 2, 27, 1, 27, 26, 27, 4, 27, 1001, 28, -1, 28, 1005, 28, 6, 99, 0, 0, 5}
+	setting := []int{9, 8, 7, 6, 5}
+	expected := 139629729
+
+	amps := make([]*Machine, len(setting))
+	for i, s := range setting {
+		amps[i] = NewMachine(codes)
+		amps[i].Feed(s)
+	}
+
+	signal := 0
+	halted := false
+	for !halted {
+		for _, amp := range amps {
+			amp.Feed(signal)
+
+			status, err := amp.Run()
+			if err != nil {
+				t.Errorf("unexpected error returned: %s", err.Error())
+				return
+			}
+
+			switch status {
+			case HasOutput:
+				signal, _ = amp.Output()
+			case Halted:
+				halted = true
+			}
+		}
+	}
+
+	if signal != expected {
+		t.Errorf("incorrect signal %v; expected %v", signal, expected)
+	}
+}
+
+func TestMachineFork(t *testing.T) {
+	codes := []int{3, 9, 1002, 9, 2, 9, 4, 9, 99, 0}
+	inputs := []int{1, 2, 3}
+	expected := []int{2, 4, 6}
+
+	m := NewMachine(codes)
+	status, _ := m.Run()
+	if status != NeedsInput {
+		t.Errorf("incorrect status %v; expected %v", status, NeedsInput)
+		return
+	}
+
+	for i, input := range inputs {
+		f := m.Fork()
+		f.Feed(input)
+
+		status, err := f.Run()
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			continue
+		}
+
+		v, _ := f.Output()
+		if status != HasOutput || v != expected[i] {
+			t.Errorf("incorrect output %v with status %v; expected %v", v, status, expected[i])
+		}
+	}
+
+	// the original is unaffected by its forks
+	m.Feed(5)
+	m.Run()
+	v, _ := m.Output()
+	if v != 10 {
+		t.Errorf("incorrect output %v; expected %v", v, 10)
+	}
+}
+
+func TestMachineFork_CopyOnWrite(t *testing.T) {
+	codes := make([]int, 3*pageSize)
+	copy(codes, []int{3, 1000, 99})
+
+	m := NewMachine(codes)
+	m.Run()
+
+	f := m.Fork()
+	for i, page := range m.inst.Memory.pages {
+		if &page[0] != &f.inst.Memory.pages[i][0] {
+			t.Errorf("page %v was copied by fork", i)
+		}
+	}
+
+	f.Feed(7)
+	f.Run()
+
+	if &m.inst.Memory.pages[1][0] == &f.inst.Memory.pages[1][0] {
+		t.Errorf("written page was not copied")
+	}
+
+	for _, i := range []int{0, 2} {
+		if &m.inst.Memory.pages[i][0] != &f.inst.Memory.pages[i][0] {
+			t.Errorf("page %v was copied without a write", i)
+		}
+	}
+
+	if v, _ := m.inst.Peek(1000); v != 0 {
+		t.Errorf("incorrect value %v in original; expected %v", v, 0)
+	}
+
+	if v, _ := f.inst.Peek(1000); v != 7 {
+		t.Errorf("incorrect value %v in fork; expected %v", v, 7)
+	}
+}
+
+func TestMachineRun_Budget(t *testing.T) {
+	m := NewMachine([]int{1101, 1, 1, 0, 1101, 1, 1, 0, 104, 7, 99})
+	m.SetBudget(2)
+
+	status, err := m.Run()
+	if status != BudgetExhausted || err != ErrBudgetExhausted {
+		t.Errorf("incorrect status %v; expected %v", status, BudgetExhausted)
+	}
+
+	if m.Steps() != 2 {
+		t.Errorf("incorrect steps %v; expected %v", m.Steps(), 2)
+	}
+
+	m.SetBudget(0)
+	status, _ = m.Run()
+	if status != HasOutput {
+		t.Errorf("incorrect status %v after raising the budget; expected %v", status, HasOutput)
+	}
+}
+
+func TestMachineRun_BudgetKept(t *testing.T) {
+	m := NewMachine([]int{1101, 1, 1, 0, 1101, 1, 1, 0, 104, 7, 99})
+	m.SetBudget(2)
+	m.Run()
+
+	restored, err := RestoreMachine(m.Snapshot())
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	data, _ := m.Snapshot().MarshalBinary()
+	s := &Snapshot{}
+	s.UnmarshalBinary(data)
+	decoded, _ := RestoreMachine(s)
+
+	for i, c := range []*Machine{m.Fork(), restored, decoded} {
+		status, _ := c.Run()
+		if status != BudgetExhausted || c.Steps() != 2 {
+			t.Errorf("incorrect status %v after %v steps for copy %v; expected %v after 2", status, c.Steps(), i+1, BudgetExhausted)
+		}
+	}
+}
+
+func TestMachineRunContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	m := NewMachine([]int{104, 7, 99})
+	status, err := m.RunContext(ctx)
+	if status != Cancelled || err != context.Canceled {
+		t.Errorf("incorrect status %v; expected %v", status, Cancelled)
+	}
+
+	status, _ = m.Run()
+	if status != HasOutput {
+		t.Errorf("incorrect status %v after resuming; expected %v", status, HasOutput)
+	}
+}
+
+func TestSteps_Interpreters(t *testing.T) {
+	codes := [][]int{
+		[]int{1101, 1, 1, 0, 99},
+		[]int{3, 0, 4, 0, 99},
+		[]int{3, 0, 4, 0, 99},
+		[]int{1101, 1, 1, -1, 99}}
+	inputs := [][]int{
+		[]int{},
+		[]int{7},
+		[]int{},
+		[]int{}}
+
+	expected := []int{2, 3, 0, 0}
+
+	for i, set := range codes {
+		comp := NewInstruction(0, append([]int{}, set...))
+		comp.Input = &valueQueue{values: append([]int{}, inputs[i]...)}
+		comp.Output = &valueQueue{}
+		result := Run(comp)
+
+		m := NewMachine(set)
+		m.Feed(inputs[i]...)
+		bm := NewBigMachine(set)
+		for _, v := range inputs[i] {
+			bm.Feed(big.NewInt(int64(v)))
+		}
+
+		for _, run := range []func() (Status, error){m.Run, bm.Run} {
+			status, _ := run()
+			for status == HasOutput {
+				status, _ = run()
+			}
+		}
+
+		steps := []int{result.Steps, m.Steps(), bm.Steps()}
+		for j, s := range steps {
+			if s != expected[i] {
+				t.Errorf("incorrect steps %v from interpreter %v for test %v; expected %v", s, j+1, i+1, expected[i])
+			}
+		}
+	}
+}
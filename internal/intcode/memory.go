@@ -0,0 +1,135 @@
+package intcode
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultMaxAddress ...
+	DefaultMaxAddress = 1 << 30
+	// pageSize is the number of values allocated at a time
+	pageSize = 512
+)
+
+// AddressError ...
+type AddressError struct {
+	Address    int
+	MaxAddress int
+}
+
+// Error ...
+func (e *AddressError) Error() string {
+	return fmt.Sprintf("address %v out of range; valid addresses are 0 to %v", e.Address, e.MaxAddress)
+}
+
+// Memory holds values addressed past the end of a loaded program. Values are
+// allocated a page at a time so a write to a high address only costs the page
+// containing it. Pages may be shared with forks of the memory, in which case
+// they are copied on the first write.
+type Memory struct {
+	MaxAddress int
+	pages      map[int][]int
+	shared     map[int]bool
+}
+
+// NewMemory ...
+func NewMemory(maxAddress int) *Memory {
+	return &Memory{
+		MaxAddress: maxAddress,
+		pages:      make(map[int][]int),
+		shared:     make(map[int]bool)}
+}
+
+func (m *Memory) check(address int) error {
+	if address < 0 || address > m.MaxAddress {
+		return &AddressError{Address: address, MaxAddress: m.MaxAddress}
+	}
+
+	return nil
+}
+
+// Load returns zero for addresses that were never written
+func (m *Memory) Load(address int) (int, error) {
+	err := m.check(address)
+	if err != nil {
+		return 0, err
+	}
+
+	page, ok := m.pages[address/pageSize]
+	if !ok {
+		return 0, nil
+	}
+
+	return page[address%pageSize], nil
+}
+
+// Store ...
+func (m *Memory) Store(address int, value int) error {
+	err := m.check(address)
+	if err != nil {
+		return err
+	}
+
+	index := address / pageSize
+	page, ok := m.pages[index]
+	switch {
+	case !ok:
+		// untouched pages read as zero so there is no need to allocate one
+		if value == 0 {
+			return nil
+		}
+
+		page = make([]int, pageSize)
+		m.pages[index] = page
+	case m.shared[index]:
+		page = append(make([]int, 0, pageSize), page...)
+		m.pages[index] = page
+		delete(m.shared, index)
+	}
+
+	page[address%pageSize] = value
+	return nil
+}
+
+// fork returns a copy of the memory that shares every page with m until
+// either side writes to it
+func (m *Memory) fork() *Memory {
+	f := NewMemory(m.MaxAddress)
+	for i, page := range m.pages {
+		f.pages[i] = page
+		f.shared[i] = true
+		m.shared[i] = true
+	}
+
+	return f
+}
+
+// pageProgram moves the loaded program into paged memory so it can be shared
+// page by page between forks
+func (i *Instruction) pageProgram() {
+	if len(i.DataSet) == 0 {
+		return
+	}
+
+	mem := i.memory()
+	if mem.MaxAddress < len(i.DataSet)-1 {
+		mem.MaxAddress = len(i.DataSet) - 1
+	}
+
+	for start := 0; start < len(i.DataSet); start += pageSize {
+		page := make([]int, pageSize)
+		copy(page, i.DataSet[start:])
+		mem.pages[start/pageSize] = page
+		delete(mem.shared, start/pageSize)
+	}
+
+	i.DataSet = nil
+	if i.cache != nil {
+		i.cache.pageProgram()
+	}
+}
+
+// Pages returns the number of allocated pages
+func (m *Memory) Pages() int {
+	return len(m.pages)
+}
@@ -0,0 +1,74 @@
+package intcode
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	mem := NewMemory(1 << 40)
+	addresses := []int{0, 511, 512, 1 << 20, 1 << 40}
+	expected := []int{1, 2, 3, 4, 5}
+
+	for i, a := range addresses {
+		err := mem.Store(a, expected[i])
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+		}
+	}
+
+	for i, a := range addresses {
+		v, err := mem.Load(a)
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+		}
+
+		if v != expected[i] {
+			t.Errorf("incorrect value %v at address %v; expected %v", v, a, expected[i])
+		}
+	}
+
+	if mem.Pages() != 4 {
+		t.Errorf("incorrect number of pages %v; expected %v", mem.Pages(), 4)
+	}
+}
+
+func TestMemory_OutOfRange(t *testing.T) {
+	mem := NewMemory(100)
+	addresses := []int{-1, 101, 1 << 50}
+
+	for _, a := range addresses {
+		err := mem.Store(a, 1)
+		if _, ok := err.(*AddressError); !ok {
+			t.Errorf("expected address error for address %v; got %v", a, err)
+		}
+
+		_, err = mem.Load(a)
+		if _, ok := err.(*AddressError); !ok {
+			t.Errorf("expected address error for address %v; got %v", a, err)
+		}
+	}
+}
+
+func TestProcess_HighAddress(t *testing.T) {
+	inputs := [][]int{
+		[]int{1101, 7, 0, 100000000, 4, 100000000, 99},
+		[]int{1101, 7, 0, 1000000000000, 99},
+		[]int{109, -10, 204, 0, 99}}
+	expectedStatus := []Status{Halted, Failed, Failed}
+	expectedOut := []string{"7\n", "", ""}
+
+	for i, set := range inputs {
+		w := bytes.NewBuffer([]byte{})
+
+		result := Process(strings.NewReader(""), w, 0, set)
+		if result.Status != expectedStatus[i] {
+			t.Errorf("incorrect status %v for test %v; expected %v", result.Status, i+1, expectedStatus[i])
+		}
+
+		if w.String() != expectedOut[i] {
+			t.Errorf("incorrect output %q for test %v; expected %q", w.String(), i+1, expectedOut[i])
+		}
+	}
+}
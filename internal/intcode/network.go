@@ -0,0 +1,343 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// NATAddress is the address of the network supervisor
+	NATAddress = 255
+	// idleThreshold is the number of consecutive empty reads after which a
+	// waiting machine is considered idle
+	idleThreshold = 2
+)
+
+var (
+	// ErrNetworkHalted is returned once every machine in a network has halted
+	ErrNetworkHalted = errors.New("every machine in the network has halted")
+	// ErrNetworkIdle is returned when the network is idle and the supervisor
+	// has nothing to send
+	ErrNetworkIdle = errors.New("network is idle")
+)
+
+// Packet is an (x, y) pair sent from one address to another
+type Packet struct {
+	Source int
+	Dest   int
+	X      int
+	Y      int
+}
+
+// Supervisor receives the packets sent to NATAddress and may restart an idle
+// network
+type Supervisor interface {
+	Receive(p Packet)
+	// Idle is called when every machine is waiting for input and no packets
+	// are queued. The returned packets are delivered.
+	Idle() []Packet
+}
+
+// NAT remembers the last packet it received and sends it to address 0 when
+// the network is idle
+type NAT struct {
+	Last *Packet
+	Sent []Packet
+}
+
+// Receive ...
+func (n *NAT) Receive(p Packet) {
+	n.Last = &p
+}
+
+// Idle ...
+func (n *NAT) Idle() []Packet {
+	if n.Last == nil {
+		return nil
+	}
+
+	p := Packet{Source: NATAddress, Dest: 0, X: n.Last.X, Y: n.Last.Y}
+	n.Sent = append(n.Sent, p)
+	return []Packet{p}
+}
+
+// node is a machine and its place in the network
+type node struct {
+	machine *Machine
+	queue   []Packet
+	// output collects values until they form a packet
+	output  []int
+	empty   int
+	waiting bool
+	halted  bool
+}
+
+// Network connects machines that exchange packets. Each machine is booted
+// with its address as its first input. When a machine reads input it receives
+// the x and y of every packet queued for it, or -1 if there are none.
+type Network struct {
+	Supervisor Supervisor
+	// Stop ends the run when it returns true for a routed packet
+	Stop func(p Packet) bool
+	// Observe is called with every routed packet in the order they are
+	// routed
+	Observe func(p Packet)
+	nodes   []*node
+	mu      sync.Mutex
+	// changed is signalled whenever a packet is queued or the run stops
+	changed *sync.Cond
+	stopped bool
+}
+
+// NewNetwork boots size copies of the program at addresses 0 to size-1. The
+// addresses must stay below NATAddress.
+func NewNetwork(set []int, size int) (*Network, error) {
+	if size < 1 || size > NATAddress {
+		return nil, fmt.Errorf("network of %v machines; expected 1 to %v", size, NATAddress)
+	}
+
+	n := &Network{nodes: make([]*node, size)}
+	n.changed = sync.NewCond(&n.mu)
+	for i := range n.nodes {
+		m := NewMachine(set)
+		m.Feed(i)
+		n.nodes[i] = &node{machine: m}
+	}
+
+	return n, nil
+}
+
+// Machine returns the machine at address
+func (n *Network) Machine(address int) *Machine {
+	return n.nodes[address].machine
+}
+
+// Send queues a packet as if it had been sent by a machine
+func (n *Network) Send(p Packet) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.route(p)
+}
+
+// route delivers a packet; the caller holds the lock
+func (n *Network) route(p Packet) error {
+	switch {
+	case p.Dest == NATAddress && n.Supervisor != nil:
+		n.Supervisor.Receive(p)
+	case p.Dest >= 0 && p.Dest < len(n.nodes):
+		n.nodes[p.Dest].queue = append(n.nodes[p.Dest].queue, p)
+	default:
+		return fmt.Errorf("packet from %v to unknown address %v", p.Source, p.Dest)
+	}
+
+	if n.Observe != nil {
+		n.Observe(p)
+	}
+
+	if n.Stop != nil && n.Stop(p) {
+		n.stopped = true
+	}
+
+	n.changed.Broadcast()
+	return nil
+}
+
+// idle reports whether every machine is waiting without traffic; the caller
+// holds the lock
+func (n *Network) idle() bool {
+	for _, nd := range n.nodes {
+		if nd.halted {
+			continue
+		}
+
+		if !nd.waiting || nd.empty < idleThreshold || len(nd.queue) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// halted reports whether every machine has halted; the caller holds the lock
+func (n *Network) halted() bool {
+	for _, nd := range n.nodes {
+		if !nd.halted {
+			return false
+		}
+	}
+
+	return true
+}
+
+// wake asks the supervisor to restart an idle network; the caller holds the
+// lock
+func (n *Network) wake() error {
+	if n.Supervisor == nil {
+		return ErrNetworkIdle
+	}
+
+	packets := n.Supervisor.Idle()
+	if len(packets) == 0 {
+		return ErrNetworkIdle
+	}
+
+	for _, p := range packets {
+		err := n.route(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// step feeds a machine its queued packets and runs it until it needs more
+// input. It returns true when the run should end.
+func (n *Network) step(address int) (bool, error) {
+	nd := n.nodes[address]
+	m := nd.machine
+
+	n.mu.Lock()
+	if n.stopped {
+		n.mu.Unlock()
+		return true, nil
+	}
+
+	if len(nd.queue) == 0 {
+		m.Feed(-1)
+		nd.empty++
+	} else {
+		for _, p := range nd.queue {
+			m.Feed(p.X, p.Y)
+		}
+		nd.queue = nil
+		nd.empty = 0
+	}
+	nd.waiting = false
+	n.mu.Unlock()
+
+	for {
+		status, err := m.Run()
+		if err != nil {
+			return true, fmt.Errorf("machine %v: %s", address, err.Error())
+		}
+
+		if status == Halted && len(nd.output) > 0 {
+			return true, fmt.Errorf("machine %v halted after sending %v of the 3 values of a packet", address, len(nd.output))
+		}
+
+		if status != HasOutput {
+			n.mu.Lock()
+			defer n.mu.Unlock()
+
+			nd.waiting = true
+			nd.halted = status == Halted
+
+			switch {
+			case n.stopped:
+				return true, nil
+			case n.halted():
+				return true, ErrNetworkHalted
+			case n.idle():
+				err := n.wake()
+				return err != nil || n.stopped, err
+			}
+
+			return false, nil
+		}
+
+		v, _ := m.Output()
+		nd.output = append(nd.output, v)
+		if len(nd.output) < 3 {
+			continue
+		}
+
+		p := Packet{Source: address, Dest: nd.output[0], X: nd.output[1], Y: nd.output[2]}
+		nd.output = nil
+
+		n.mu.Lock()
+		err = n.route(p)
+		n.mu.Unlock()
+		if err != nil {
+			return true, err
+		}
+	}
+}
+
+// Run steps the machines in address order in a single goroutine, so the same
+// program always produces the same traffic. It returns nil once Stop returns
+// true.
+func (n *Network) Run(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		for address, nd := range n.nodes {
+			if nd.halted {
+				continue
+			}
+
+			done, err := n.step(address)
+			if done {
+				return err
+			}
+		}
+	}
+}
+
+// RunConcurrent runs every machine in its own goroutine. Packets are
+// delivered in the order they are routed but the interleaving of machines is
+// up to the scheduler. A machine that has read no packets idleThreshold times
+// in a row sleeps until a packet is queued for it.
+func (n *Network) RunConcurrent(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// wake every sleeping machine once the run ends
+	go func() {
+		<-ctx.Done()
+		n.mu.Lock()
+		n.changed.Broadcast()
+		n.mu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var result error
+
+	for address := range n.nodes {
+		wg.Add(1)
+		go func(address int) {
+			defer wg.Done()
+
+			nd := n.nodes[address]
+			for ctx.Err() == nil {
+				done, err := n.step(address)
+				if done {
+					once.Do(func() { result = err })
+					cancel()
+					return
+				}
+
+				n.mu.Lock()
+				for !nd.halted && !n.stopped && len(nd.queue) == 0 && nd.empty >= idleThreshold && ctx.Err() == nil {
+					n.changed.Wait()
+				}
+				halted := nd.halted
+				n.mu.Unlock()
+				if halted {
+					return
+				}
+			}
+		}(address)
+	}
+
+	wg.Wait()
+
+	once.Do(func() { result = ctx.Err() })
+	return result
+}
@@ -0,0 +1,180 @@
+package intcode
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// relaySource passes every packet it receives on to the next address with x
+// incremented; the last of four machines sends to the NAT
+const relaySource = `
+        in [@addr]
+        add [@addr], #1, [@dest]
+        eq [@dest], #4, [@last]
+        jf [@last], @loop
+        add #255, #0, [@dest]
+loop:   in [@x]
+        eq [@x], #-1, [@empty]
+        jt [@empty], @loop
+        in [@y]
+        add [@x], #1, [@x]
+        out [@dest]
+        out [@x]
+        out [@y]
+        jt #1, @loop
+addr:   .data 0
+dest:   .data 0
+last:   .data 0
+x:      .data 0
+y:      .data 0
+empty:  .data 0
+`
+
+func relayNetwork(t *testing.T) *Network {
+	set, err := AssembleString(relaySource)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err.Error())
+	}
+
+	n, err := NewNetwork(set, 4)
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err.Error())
+	}
+
+	return n
+}
+
+func TestNetworkRun(t *testing.T) {
+	n := relayNetwork(t)
+	n.Supervisor = &NAT{}
+	n.Stop = func(p Packet) bool {
+		return p.Dest == NATAddress
+	}
+
+	traffic := []Packet{}
+	n.Observe = func(p Packet) {
+		traffic = append(traffic, p)
+	}
+
+	n.Send(Packet{Source: -1, Dest: 0, X: 10, Y: 7})
+	err := n.Run(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	expected := []Packet{
+		Packet{Source: -1, Dest: 0, X: 10, Y: 7},
+		Packet{Source: 0, Dest: 1, X: 11, Y: 7},
+		Packet{Source: 1, Dest: 2, X: 12, Y: 7},
+		Packet{Source: 2, Dest: 3, X: 13, Y: 7},
+		Packet{Source: 3, Dest: NATAddress, X: 14, Y: 7}}
+
+	if len(traffic) != len(expected) {
+		t.Errorf("incorrect traffic %v; expected %v", traffic, expected)
+		return
+	}
+
+	for i, p := range traffic {
+		if p != expected[i] {
+			t.Errorf("incorrect packet %v; expected %v", p, expected[i])
+		}
+	}
+}
+
+// natNetwork stops the first time the NAT sends the same y twice in a row
+func natNetwork(t *testing.T) (*Network, *NAT, *[]Packet) {
+	n := relayNetwork(t)
+	nat := &NAT{}
+	n.Supervisor = nat
+	n.Stop = func(p Packet) bool {
+		s := nat.Sent
+		return p.Source == NATAddress && len(s) > 1 && s[len(s)-1].Y == s[len(s)-2].Y
+	}
+
+	traffic := []Packet{}
+	n.Observe = func(p Packet) {
+		traffic = append(traffic, p)
+	}
+
+	n.Send(Packet{Source: -1, Dest: 0, X: 0, Y: 3})
+	return n, nat, &traffic
+}
+
+func TestNetworkRun_Replay(t *testing.T) {
+	first, _, firstTraffic := natNetwork(t)
+	second, nat, secondTraffic := natNetwork(t)
+
+	for _, n := range []*Network{first, second} {
+		err := n.Run(context.Background())
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			return
+		}
+	}
+
+	if len(*firstTraffic) != len(*secondTraffic) {
+		t.Errorf("incorrect replay of %v packets; expected %v", len(*secondTraffic), len(*firstTraffic))
+		return
+	}
+
+	for i, p := range *firstTraffic {
+		if (*secondTraffic)[i] != p {
+			t.Errorf("incorrect replayed packet %v; expected %v", (*secondTraffic)[i], p)
+		}
+	}
+
+	last := nat.Sent[len(nat.Sent)-1]
+	if len(nat.Sent) != 2 || last.X != 8 || last.Y != 3 {
+		t.Errorf("incorrect NAT packets %v; expected two with the second at x %v", nat.Sent, 8)
+	}
+}
+
+func TestNetworkRunConcurrent(t *testing.T) {
+	n, nat, _ := natNetwork(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := n.RunConcurrent(ctx)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	last := nat.Sent[len(nat.Sent)-1]
+	if len(nat.Sent) != 2 || last.X != 8 || last.Y != 3 {
+		t.Errorf("incorrect NAT packets %v; expected two with the second at x %v", nat.Sent, 8)
+	}
+}
+
+func TestNetworkRun_Errors(t *testing.T) {
+	idle := relayNetwork(t)
+	if err := idle.Run(context.Background()); err != ErrNetworkIdle {
+		t.Errorf("incorrect error %v; expected %v", err, ErrNetworkIdle)
+	}
+
+	unknown := relayNetwork(t)
+	unknown.Send(Packet{Source: -1, Dest: 3, X: 1, Y: 1})
+	if err := unknown.Run(context.Background()); err == nil {
+		t.Errorf("expected error sending to the NAT without a supervisor")
+	}
+
+	halted, _ := NewNetwork([]int{3, 0, 99}, 3)
+	if err := halted.RunConcurrent(context.Background()); err != ErrNetworkHalted {
+		t.Errorf("incorrect error %v; expected %v", err, ErrNetworkHalted)
+	}
+
+	// sends the destination and x of a packet but not y
+	partial, _ := NewNetwork([]int{3, 0, 104, 1, 104, 2, 99}, 2)
+	if err := partial.Run(context.Background()); err == nil {
+		t.Errorf("expected error for a machine halting part way through a packet")
+	}
+
+	for _, size := range []int{0, -1, NATAddress + 1} {
+		if _, err := NewNetwork([]int{99}, size); err == nil {
+			t.Errorf("expected error creating a network of %v machines", size)
+		}
+	}
+}
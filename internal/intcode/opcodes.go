@@ -0,0 +1,346 @@
+package intcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Handler executes a decoded instruction
+type Handler func(i *Instruction) error
+
+// OpInfo describes an opcode. Writes lists the indexes of the parameters the
+// instruction stores its result in; they are reported as addresses rather
+// than values by tracers and must not be read by the handler.
+//
+// ReadsInput, WritesOutput and Halts tell a Machine how the instruction
+// affects its status: it waits with NeedsInput before an instruction that
+// reads input when none is queued, stops with HasOutput after one that writes
+// output and stops with Halted after one that halts. The handler of a halting
+// instruction returns nil or the error the standard hlt returns.
+type OpInfo struct {
+	Code         OpCode
+	Mnemonic     string
+	Arity        int
+	Writes       []int
+	ReadsInput   bool
+	WritesOutput bool
+	Halts        bool
+	Handler      Handler
+}
+
+// writes reports whether the parameter at index is written to
+func (o *OpInfo) writes(index int) bool {
+	for _, w := range o.Writes {
+		if w == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpTable is the set of opcodes understood by the decoder, disassembler,
+// assembler and executor
+type OpTable struct {
+	ops   map[OpCode]*OpInfo
+	names map[string]OpCode
+}
+
+// DefaultOps holds the standard instruction set and is used by every
+// instruction without its own table. Register experimental opcodes before
+// any program is run.
+var DefaultOps = NewStandardOpTable()
+
+// NewOpTable returns a table without any opcodes
+func NewOpTable() *OpTable {
+	return &OpTable{
+		ops:   make(map[OpCode]*OpInfo),
+		names: make(map[string]OpCode)}
+}
+
+// NewStandardOpTable returns a table with the standard instruction set
+func NewStandardOpTable() *OpTable {
+	t := NewOpTable()
+	for _, info := range standardOps {
+		err := t.Register(info)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return t
+}
+
+var standardOps = []OpInfo{
+	OpInfo{Code: AddOp, Mnemonic: "add", Arity: 3, Writes: []int{2}, Handler: execAdd},
+	OpInfo{Code: MultiplyOp, Mnemonic: "mul", Arity: 3, Writes: []int{2}, Handler: execMult},
+	OpInfo{Code: InputOp, Mnemonic: "in", Arity: 1, Writes: []int{0}, ReadsInput: true, Handler: execInput},
+	OpInfo{Code: OutputOp, Mnemonic: "out", Arity: 1, WritesOutput: true, Handler: execOutput},
+	OpInfo{Code: JumpTrue, Mnemonic: "jt", Arity: 2, Handler: execJumpTrue},
+	OpInfo{Code: JumpFalse, Mnemonic: "jf", Arity: 2, Handler: execJumpFalse},
+	OpInfo{Code: LessThan, Mnemonic: "lt", Arity: 3, Writes: []int{2}, Handler: execLessThan},
+	OpInfo{Code: Equals, Mnemonic: "eq", Arity: 3, Writes: []int{2}, Handler: execEquals},
+	OpInfo{Code: RelativeBase, Mnemonic: "arb", Arity: 1, Handler: execRelativeBase},
+	OpInfo{Code: TerminateOp, Mnemonic: "hlt", Arity: 0, Halts: true, Handler: execTerminate}}
+
+// Register adds an opcode to the table
+func (t *OpTable) Register(info OpInfo) error {
+	switch {
+	case info.Code <= 0 || info.Code > 99:
+		return fmt.Errorf("opcode %v out of range; must be 1 to 99", int(info.Code))
+	case info.Arity < 0 || info.Arity > modeCount:
+		return fmt.Errorf("opcode %v takes %v parameters; at most %v are supported", int(info.Code), info.Arity, modeCount)
+	case info.Mnemonic == "" || strings.HasPrefix(info.Mnemonic, ".") || strings.ContainsAny(info.Mnemonic, " \t,;:[]#@"):
+		return fmt.Errorf("invalid mnemonic %q for opcode %v", info.Mnemonic, int(info.Code))
+	case info.Handler == nil:
+		return fmt.Errorf("opcode %v has no handler", int(info.Code))
+	}
+
+	if _, ok := t.ops[info.Code]; ok {
+		return fmt.Errorf("opcode %v is already registered", int(info.Code))
+	}
+
+	if _, ok := t.names[info.Mnemonic]; ok {
+		return fmt.Errorf("mnemonic %s is already registered", info.Mnemonic)
+	}
+
+	for _, w := range info.Writes {
+		if w < 0 || w >= info.Arity {
+			return fmt.Errorf("opcode %v writes to parameter %v of %v", int(info.Code), w, info.Arity)
+		}
+	}
+
+	op := info
+	op.Writes = append([]int{}, info.Writes...)
+	t.ops[op.Code] = &op
+	t.names[op.Mnemonic] = op.Code
+
+	return nil
+}
+
+// Register adds an opcode to DefaultOps
+func Register(info OpInfo) error {
+	return DefaultOps.Register(info)
+}
+
+// Lookup ...
+func (t *OpTable) Lookup(code OpCode) (*OpInfo, bool) {
+	op, ok := t.ops[code]
+	return op, ok
+}
+
+// Mnemonic ...
+func (t *OpTable) Mnemonic(code OpCode) (string, bool) {
+	op, ok := t.ops[code]
+	if !ok {
+		return "", false
+	}
+
+	return op.Mnemonic, true
+}
+
+// ParseMnemonic ...
+func (t *OpTable) ParseMnemonic(name string) (OpCode, bool) {
+	code, ok := t.names[name]
+	return code, ok
+}
+
+// Arity ...
+func (t *OpTable) Arity(code OpCode) (int, bool) {
+	op, ok := t.ops[code]
+	if !ok {
+		return 0, false
+	}
+
+	return op.Arity, true
+}
+
+// writesTo reports whether the parameter at index is written to by code
+func (t *OpTable) writesTo(code OpCode, index int) bool {
+	op, ok := t.ops[code]
+	return ok && op.writes(index)
+}
+
+func (i *Instruction) ops() *OpTable {
+	if i.Ops == nil {
+		return DefaultOps
+	}
+
+	return i.Ops
+}
+
+// Value returns the value of the parameter at index according to its mode
+func (i *Instruction) Value(index int) (int, error) {
+	return i.getValue(index)
+}
+
+// SetValue stores value at the address the parameter at index refers to
+func (i *Instruction) SetValue(index int, value int) error {
+	return i.setValue(index, value)
+}
+
+// ReadInput ...
+func (i *Instruction) ReadInput() (int, error) {
+	return i.getInput()
+}
+
+// WriteOutput ...
+func (i *Instruction) WriteOutput(value int) error {
+	return i.setOutput(value)
+}
+
+// Jump continues execution at position after the current instruction
+func (i *Instruction) Jump(position int) {
+	i.setNext(position)
+}
+
+func execAdd(i *Instruction) error {
+	v1, err := i.getValue(0)
+	if err != nil {
+		return err
+	}
+
+	v2, err := i.getValue(1)
+	if err != nil {
+		return err
+	}
+
+	r, err := i.add(v1, v2)
+	if err != nil {
+		return err
+	}
+
+	return i.setValue(2, r)
+}
+
+func execMult(i *Instruction) error {
+	v1, err := i.getValue(0)
+	if err != nil {
+		return err
+	}
+
+	v2, err := i.getValue(1)
+	if err != nil {
+		return err
+	}
+
+	r, err := i.mult(v1, v2)
+	if err != nil {
+		return err
+	}
+
+	return i.setValue(2, r)
+}
+
+func execInput(i *Instruction) error {
+	input, err := i.getInput()
+	if err != nil {
+		return err
+	}
+
+	return i.setValue(0, input)
+}
+
+func execOutput(i *Instruction) error {
+	v1, err := i.getValue(0)
+	if err != nil {
+		return err
+	}
+
+	return i.setOutput(v1)
+}
+
+func execJumpTrue(i *Instruction) error {
+	v1, err := i.getValue(0)
+	if err != nil {
+		return err
+	}
+
+	// jump to position if value is not zero
+	if v1 != 0 {
+		v2, err := i.getValue(1)
+		if err != nil {
+			return err
+		}
+
+		i.setNext(v2)
+	}
+
+	return nil
+}
+
+func execJumpFalse(i *Instruction) error {
+	v1, err := i.getValue(0)
+	if err != nil {
+		return err
+	}
+
+	// jump to position if value is zero
+	if v1 == 0 {
+		v2, err := i.getValue(1)
+		if err != nil {
+			return err
+		}
+
+		i.setNext(v2)
+	}
+
+	return nil
+}
+
+func execLessThan(i *Instruction) error {
+	v1, err := i.getValue(0)
+	if err != nil {
+		return err
+	}
+
+	v2, err := i.getValue(1)
+	if err != nil {
+		return err
+	}
+
+	// if first value is less than second value, set third value to 1 else 0
+	if v1 < v2 {
+		return i.setValue(2, 1)
+	}
+
+	return i.setValue(2, 0)
+}
+
+func execEquals(i *Instruction) error {
+	v1, err := i.getValue(0)
+	if err != nil {
+		return err
+	}
+
+	v2, err := i.getValue(1)
+	if err != nil {
+		return err
+	}
+
+	// if first value is equal to second value, set third value to 1 else 0
+	if v1 == v2 {
+		return i.setValue(2, 1)
+	}
+
+	return i.setValue(2, 0)
+}
+
+func execRelativeBase(i *Instruction) error {
+	v1, err := i.getValue(0)
+	if err != nil {
+		return err
+	}
+
+	r, err := i.add(i.RelPos, v1)
+	if err != nil {
+		return err
+	}
+
+	i.RelPos = r
+	return nil
+}
+
+func execTerminate(i *Instruction) error {
+	return &CodeTerminationError{exitCode: 0, message: "success"}
+}
@@ -0,0 +1,128 @@
+package intcode
+
+import (
+	"strings"
+	"testing"
+)
+
+// swapOps returns a standard table extended with an opcode that swaps the
+// values at two addresses
+func swapOps(t *testing.T) *OpTable {
+	ops := NewStandardOpTable()
+	err := ops.Register(OpInfo{
+		Code:     10,
+		Mnemonic: "swp",
+		Arity:    2,
+		Writes:   []int{0, 1},
+		Handler: func(i *Instruction) error {
+			a, b := i.Parameters[0].Value, i.Parameters[1].Value
+			va, err := i.Peek(a)
+			if err != nil {
+				return err
+			}
+
+			vb, err := i.Peek(b)
+			if err != nil {
+				return err
+			}
+
+			err = i.SetValue(0, vb)
+			if err != nil {
+				return err
+			}
+
+			return i.SetValue(1, va)
+		}})
+
+	if err != nil {
+		t.Fatalf("unexpected error returned: %s", err.Error())
+	}
+
+	return ops
+}
+
+func TestOpTableRegister_Invalid(t *testing.T) {
+	infos := []OpInfo{
+		OpInfo{Code: 0, Mnemonic: "nop", Handler: execTerminate},
+		OpInfo{Code: 100, Mnemonic: "nop", Handler: execTerminate},
+		OpInfo{Code: AddOp, Mnemonic: "nop", Handler: execTerminate},
+		OpInfo{Code: 10, Mnemonic: "add", Handler: execTerminate},
+		OpInfo{Code: 10, Mnemonic: ".nop", Handler: execTerminate},
+		OpInfo{Code: 10, Mnemonic: "nop"},
+		OpInfo{Code: 10, Mnemonic: "nop", Arity: modeCount + 1, Handler: execTerminate},
+		OpInfo{Code: 10, Mnemonic: "nop", Arity: 1, Writes: []int{1}, Handler: execTerminate}}
+
+	for _, info := range infos {
+		err := NewStandardOpTable().Register(info)
+		if err == nil {
+			t.Errorf("expected error registering %+v", info)
+		}
+	}
+}
+
+func TestOpTable_Consistent(t *testing.T) {
+	ops := swapOps(t)
+	src := "swp [8], [9]\nout [8]\nout [9]\nhlt\n.data 3, 4"
+	set, err := ops.Assemble(strings.NewReader(src))
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	expected := []int{10, 8, 9, 4, 8, 4, 9, 99, 3, 4}
+	for i, e := range expected {
+		if i >= len(set) || set[i] != e {
+			t.Errorf("incorrect program %v; expected %v", set, expected)
+			break
+		}
+	}
+
+	lines := ops.Disassemble(set)
+	if len(lines) == 0 || lines[0].String() != "     0  swp   [8], [9]" {
+		t.Errorf("incorrect disassembly %v", lines)
+	}
+
+	if _, ok := DisassembleAt(0, set); ok {
+		t.Errorf("default table decoded an unregistered opcode")
+	}
+
+	m := NewMachine(set)
+	m.SetOpTable(ops)
+	outputs := []int{}
+	for {
+		status, err := m.Run()
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			return
+		}
+
+		if status != HasOutput {
+			break
+		}
+
+		v, _ := m.Output()
+		outputs = append(outputs, v)
+	}
+
+	if len(outputs) != 2 || outputs[0] != 4 || outputs[1] != 3 {
+		t.Errorf("incorrect outputs %v; expected %v", outputs, []int{4, 3})
+	}
+}
+
+func TestOpTable_Trace(t *testing.T) {
+	comp := NewInstruction(0, []int{10, 4, 5, 99, 1, 2})
+	comp.Ops = swapOps(t)
+	tracer := &recordingTracer{}
+	comp.Tracer = tracer
+	Run(comp)
+
+	if len(tracer.after) == 0 {
+		t.Errorf("no events traced")
+		return
+	}
+
+	e := tracer.after[0]
+	if e.Mnemonic != "swp" || e.Operands[0] != 4 || e.Operands[1] != 5 || len(e.Writes) != 2 {
+		t.Errorf("incorrect event %+v", e)
+	}
+}
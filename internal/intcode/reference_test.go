@@ -0,0 +1,321 @@
+package intcode
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// refStep describes one instruction executed by either interpreter. Writes
+// and Output are only compared for instructions that did not fail.
+type refStep struct {
+	Position int
+	Op       OpCode
+	RelBase  int
+	Writes   []MemoryWrite
+	Output   []int
+	Failed   bool
+}
+
+func (s *refStep) String() string {
+	if s == nil {
+		return "nothing"
+	}
+
+	if s.Failed {
+		return fmt.Sprintf("op %v at ip %v failing", int(s.Op), s.Position)
+	}
+
+	return fmt.Sprintf("op %v at ip %v writing %v, outputting %v, rb %v", int(s.Op), s.Position, s.Writes, s.Output, s.RelBase)
+}
+
+func (s *refStep) equal(o *refStep) bool {
+	if s.Position != o.Position || s.Op != o.Op || s.Failed != o.Failed {
+		return false
+	}
+
+	if s.Failed {
+		return true
+	}
+
+	if s.RelBase != o.RelBase || len(s.Writes) != len(o.Writes) || len(s.Output) != len(o.Output) {
+		return false
+	}
+
+	for i := range s.Writes {
+		if s.Writes[i] != o.Writes[i] {
+			return false
+		}
+	}
+
+	return equalCodes(s.Output, o.Output)
+}
+
+// refMachine is a deliberately naive interpreter for the standard instruction
+// set sharing no code with Instruction. It mirrors the documented behaviour of
+// Instruction, including immediate mode writes storing to the parameter's own
+// address and addresses past maxAddress being out of range unless they are
+// part of the program.
+type refMachine struct {
+	mem        map[int]int
+	size       int
+	maxAddress int
+	ip         int
+	rb         int
+	input      []int
+}
+
+func newRefMachine(program []int, maxAddress int, input []int) *refMachine {
+	m := &refMachine{mem: make(map[int]int), size: len(program), maxAddress: maxAddress, input: input}
+	for a, v := range program {
+		m.mem[a] = v
+	}
+
+	return m
+}
+
+func (m *refMachine) valid(address int) bool {
+	return address >= 0 && (address < m.size || address <= m.maxAddress)
+}
+
+// run executes at most budget instructions and returns every executed
+// instruction with the status the machine stopped in
+func (m *refMachine) run(budget int) ([]*refStep, Status) {
+	steps := []*refStep{}
+
+	for len(steps) < budget {
+		if !m.valid(m.ip) {
+			return steps, Failed
+		}
+
+		value := m.mem[m.ip]
+		if value < 0 || value/1000000 != 0 {
+			return steps, Failed
+		}
+
+		op := OpCode(value % 100)
+		arity := 0
+		switch op {
+		case AddOp, MultiplyOp, LessThan, Equals:
+			arity = 3
+		case JumpTrue, JumpFalse:
+			arity = 2
+		case InputOp, OutputOp, RelativeBase:
+			arity = 1
+		case TerminateOp:
+		default:
+			return steps, Failed
+		}
+
+		modes := []int{value / 100 % 10, value / 1000 % 10, value / 10000 % 10, value / 100000 % 10}
+		params := make([]int, arity)
+		for k := range params {
+			if modes[k] > 2 || !m.valid(m.ip+1+k) {
+				return steps, Failed
+			}
+			params[k] = m.mem[m.ip+1+k]
+		}
+		for k := arity; k < len(modes); k++ {
+			if modes[k] > 2 {
+				return steps, Failed
+			}
+		}
+
+		// address returns where parameter k points
+		address := func(k int) int {
+			switch modes[k] {
+			case 1:
+				return m.ip + 1 + k
+			case 2:
+				return m.rb + params[k]
+			}
+			return params[k]
+		}
+
+		s := &refStep{Position: m.ip, Op: op}
+		steps = append(steps, s)
+
+		read := func(k int) (int, bool) {
+			if modes[k] == 1 {
+				return params[k], true
+			}
+			a := address(k)
+			if !m.valid(a) {
+				s.Failed = true
+				return 0, false
+			}
+			return m.mem[a], true
+		}
+
+		write := func(k int, v int) bool {
+			a := address(k)
+			if !m.valid(a) {
+				s.Failed = true
+				return false
+			}
+			s.Writes = append(s.Writes, MemoryWrite{Address: a, Value: v})
+			m.mem[a] = v
+			return true
+		}
+
+		next := m.ip + 1 + arity
+		switch op {
+		case AddOp, MultiplyOp, LessThan, Equals:
+			a, ok := read(0)
+			if !ok {
+				return steps, Failed
+			}
+			b, ok := read(1)
+			if !ok {
+				return steps, Failed
+			}
+
+			r := 0
+			switch {
+			case op == AddOp:
+				r = a + b
+			case op == MultiplyOp:
+				r = a * b
+			case op == LessThan && a < b, op == Equals && a == b:
+				r = 1
+			}
+
+			if !write(2, r) {
+				return steps, Failed
+			}
+		case InputOp:
+			if len(m.input) == 0 {
+				s.Failed = true
+				return steps, NeedsInput
+			}
+			if !write(0, m.input[0]) {
+				return steps, Failed
+			}
+			m.input = m.input[1:]
+		case OutputOp:
+			a, ok := read(0)
+			if !ok {
+				return steps, Failed
+			}
+			s.Output = append(s.Output, a)
+		case JumpTrue, JumpFalse:
+			a, ok := read(0)
+			if !ok {
+				return steps, Failed
+			}
+			if (a != 0) == (op == JumpTrue) {
+				if next, ok = read(1); !ok {
+					return steps, Failed
+				}
+			}
+		case RelativeBase:
+			a, ok := read(0)
+			if !ok {
+				return steps, Failed
+			}
+			m.rb += a
+		case TerminateOp:
+			s.RelBase = m.rb
+			return steps, Halted
+		}
+
+		s.RelBase = m.rb
+		m.ip = next
+	}
+
+	return steps, BudgetExhausted
+}
+
+// stepRecorder collects the instructions executed by an Instruction in the
+// same form as refMachine
+type stepRecorder struct {
+	steps  []*refStep
+	output []int
+}
+
+func (r *stepRecorder) Before(e *TraceEvent) {}
+
+func (r *stepRecorder) After(e *TraceEvent) {
+	r.steps = append(r.steps, &refStep{
+		Position: e.Position,
+		Op:       e.Op,
+		RelBase:  e.RelBaseAfter,
+		Writes:   e.Writes,
+		Output:   r.output,
+		Failed:   e.Error != ""})
+	r.output = nil
+}
+
+func (r *stepRecorder) WriteValue(v int) error {
+	r.output = append(r.output, v)
+	return nil
+}
+
+type sliceInput struct {
+	values []int
+}
+
+func (s *sliceInput) ReadValue() (int, error) {
+	if len(s.values) == 0 {
+		return 0, io.EOF
+	}
+
+	v := s.values[0]
+	s.values = s.values[1:]
+	return v, nil
+}
+
+// divergence is the first instruction on which the interpreters disagree.
+// A nil step means that interpreter had already stopped.
+type divergence struct {
+	Step     int
+	Got      *refStep
+	Expected *refStep
+	Status   Status
+	Want     Status
+}
+
+func (d *divergence) String() string {
+	if d.Got == nil && d.Expected == nil {
+		return fmt.Sprintf("status %v after %v steps; reference stopped with %v", d.Status, d.Step, d.Want)
+	}
+
+	return fmt.Sprintf("step %v: executed %s; reference executed %s", d.Step, d.Got, d.Expected)
+}
+
+// differ runs the program on an Instruction using ops and on refMachine with
+// the same input, memory limit and budget. It returns nil if both executed
+// the same instructions with the same effects and stopped the same way.
+func differ(ops *OpTable, program []int, input []int, maxAddress int, budget int) *divergence {
+	rec := &stepRecorder{}
+	comp := NewInstruction(0, append([]int{}, program...))
+	comp.Ops = ops
+	comp.Memory = NewMemory(maxAddress)
+	comp.Input = &sliceInput{values: append([]int{}, input...)}
+	comp.Output = rec
+	comp.Tracer = rec
+	result := RunContext(context.Background(), comp, budget)
+
+	ref := newRefMachine(program, maxAddress, append([]int{}, input...))
+	steps, status := ref.run(budget)
+
+	for i := 0; i < len(rec.steps) || i < len(steps); i++ {
+		var got, expected *refStep
+		if i < len(rec.steps) {
+			got = rec.steps[i]
+		}
+		if i < len(steps) {
+			expected = steps[i]
+		}
+
+		if got == nil || expected == nil || !got.equal(expected) {
+			return &divergence{Step: i, Got: got, Expected: expected, Status: result.Status, Want: status}
+		}
+	}
+
+	if result.Status != status {
+		return &divergence{Step: len(steps), Status: result.Status, Want: status}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package intcode
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Result describes how a program run ended. Steps is the number of
+// instructions the program has completed, including the one it halted at.
+type Result struct {
+	Status Status
+	Fault  *Fault
+	Steps  int
+}
+
+// Fault describes the instruction a program failed on
+type Fault struct {
+	Position int    `json:"position"`
+	Op       int    `json:"op"`
+	Reason   string `json:"reason"`
+}
+
+// Error ...
+func (f *Fault) Error() string {
+	return fmt.Sprintf("instruction %v at position %v failed; %s", f.Op, f.Position, f.Reason)
+}
+
+// ExitCode ...
+func (e *CodeTerminationError) ExitCode() int {
+	return e.exitCode
+}
+
+// newResult classifies the error that stopped the instruction loop
+func newResult(comp *Instruction, err error) Result {
+	r := Result{Status: Failed, Steps: comp.executed}
+
+	if terminated(err) {
+		r.Status = Halted
+		return r
+	}
+
+	switch err {
+	case io.EOF:
+		r.Status = NeedsInput
+	case context.Canceled, context.DeadlineExceeded:
+		r.Status = Cancelled
+	default:
+		r.Fault = newFault(comp, err)
+	}
+
+	return r
+}
+
+// terminated reports whether err is the successful end of the program
+func terminated(err error) bool {
+	e, ok := err.(*CodeTerminationError)
+	return ok && e.exitCode == 0
+}
+
+func newFault(comp *Instruction, err error) *Fault {
+	f := &Fault{
+		Position: comp.Position,
+		Reason:   err.Error()}
+
+	if op, err := comp.read(comp.Position); err == nil {
+		f.Op = op
+	}
+
+	return f
+}
@@ -0,0 +1,272 @@
+package intcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// snapshotMagic starts every binary snapshot and is followed by a format
+// version byte
+var snapshotMagic = []byte{'I', 'C', 'S', 'N', 'A', 'P'}
+
+// snapshotVersion is the binary format written and read by MarshalBinary and
+// UnmarshalBinary
+const snapshotVersion = 1
+
+// Snapshot is the complete state of a Machine. It can be stored as JSON or,
+// with MarshalBinary, in a compact binary form.
+type Snapshot struct {
+	Program    []int         `json:"program"`
+	Pages      map[int][]int `json:"pages,omitempty"`
+	MaxAddress int           `json:"max_address"`
+	Position   int           `json:"ip"`
+	RelBase    int           `json:"rb"`
+	Steps      int           `json:"steps"`
+	Arithmetic Arithmetic    `json:"arithmetic"`
+	Budget     int           `json:"budget"`
+	Input      []int         `json:"input"`
+	Output     []int         `json:"output"`
+	Halted     bool          `json:"halted"`
+	Fault      *Fault        `json:"fault,omitempty"`
+}
+
+// Snapshot copies the state of the machine. Position is the address of the
+// next instruction to execute.
+func (m *Machine) Snapshot() *Snapshot {
+	mem := m.inst.memory()
+	s := &Snapshot{
+		Program:    append([]int{}, m.inst.DataSet...),
+		Pages:      make(map[int][]int, len(mem.pages)),
+		MaxAddress: mem.MaxAddress,
+		Position:   m.nextPosition(),
+		RelBase:    m.inst.RelPos,
+		Steps:      m.inst.executed,
+		Arithmetic: m.inst.Arithmetic,
+		Budget:     m.budget,
+		Input:      append([]int{}, m.input.values...),
+		Output:     append([]int{}, m.output.values...),
+		Halted:     m.halted}
+
+	for i, page := range mem.pages {
+		s.Pages[i] = append([]int{}, page...)
+	}
+
+	if m.fault != nil {
+		f := *m.fault
+		s.Fault = &f
+	}
+
+	return s
+}
+
+// nextPosition is the address of the instruction the next call to Run starts
+// with
+func (m *Machine) nextPosition() int {
+	if m.decoded || m.halted || m.fault != nil {
+		return m.inst.Position
+	}
+
+	if m.inst.jumped {
+		return m.inst.Next
+	}
+
+	return m.inst.Position + len(m.inst.Parameters) + 1
+}
+
+// RestoreMachine creates a machine that continues from a snapshot
+func RestoreMachine(s *Snapshot) (*Machine, error) {
+	err := s.Arithmetic.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewMachine(s.Program)
+	m.inst = newInstructionSet(s.Position, m.inst.DataSet)
+	m.inst.Input = m.input
+	m.inst.Output = m.output
+	m.inst.RelPos = s.RelBase
+	m.inst.executed = s.Steps
+	m.inst.Arithmetic = s.Arithmetic
+	m.budget = s.Budget
+	m.inst.Memory = NewMemory(s.MaxAddress)
+	for i, page := range s.Pages {
+		if len(page) > pageSize {
+			return nil, fmt.Errorf("page %v holds %v values; expected at most %v", i, len(page), pageSize)
+		}
+
+		p := make([]int, pageSize)
+		copy(p, page)
+		m.inst.Memory.pages[i] = p
+	}
+
+	m.input.values = append([]int{}, s.Input...)
+	m.output.values = append([]int{}, s.Output...)
+	m.halted = s.Halted
+
+	// a halted or failed machine reports the instruction it stopped at
+	if s.Halted || s.Fault != nil {
+		m.inst.Position = s.Position
+		m.inst.jumped = false
+	}
+
+	if s.Fault != nil {
+		f := *s.Fault
+		m.fault = &f
+	}
+
+	return m, nil
+}
+
+// MarshalBinary encodes the snapshot as signed varints following a magic
+// header
+func (s *Snapshot) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.Write(snapshotMagic)
+	buf.WriteByte(snapshotVersion)
+
+	put := func(v int) {
+		b := make([]byte, binary.MaxVarintLen64)
+		buf.Write(b[:binary.PutVarint(b, int64(v))])
+	}
+	putSlice := func(values []int) {
+		put(len(values))
+		for _, v := range values {
+			put(v)
+		}
+	}
+
+	put(s.MaxAddress)
+	put(s.Position)
+	put(s.RelBase)
+	put(s.Steps)
+	put(int(s.Arithmetic))
+	put(s.Budget)
+	putSlice(s.Program)
+
+	// pages are written in order so equal snapshots encode identically
+	indexes := []int{}
+	for i := range s.Pages {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	put(len(indexes))
+	for _, i := range indexes {
+		put(i)
+		putSlice(s.Pages[i])
+	}
+
+	putSlice(s.Input)
+	putSlice(s.Output)
+
+	flags := 0
+	if s.Halted {
+		flags |= 1
+	}
+	if s.Fault != nil {
+		flags |= 2
+	}
+	put(flags)
+
+	if s.Fault != nil {
+		put(s.Fault.Position)
+		put(s.Fault.Op)
+		put(len(s.Fault.Reason))
+		buf.WriteString(s.Fault.Reason)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary ...
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, snapshotMagic) || len(data) == len(snapshotMagic) {
+		return errors.New("not an intcode snapshot")
+	}
+
+	version := int(data[len(snapshotMagic)])
+	if version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %v", version)
+	}
+
+	r := bytes.NewReader(data[len(snapshotMagic)+1:])
+	var err error
+	get := func() int {
+		if err != nil {
+			return 0
+		}
+
+		var v int64
+		v, err = binary.ReadVarint(r)
+		return int(v)
+	}
+	getSlice := func() []int {
+		n := get()
+		if err == nil && (n < 0 || n > r.Len()) {
+			err = fmt.Errorf("invalid length %v", n)
+		}
+
+		if err != nil {
+			return nil
+		}
+
+		values := make([]int, n)
+		for i := range values {
+			values[i] = get()
+		}
+		return values
+	}
+
+	*s = Snapshot{}
+	s.MaxAddress = get()
+	s.Position = get()
+	s.RelBase = get()
+	s.Steps = get()
+	s.Arithmetic = Arithmetic(get())
+	s.Budget = get()
+	s.Program = getSlice()
+
+	pages := get()
+	if err == nil && (pages < 0 || pages > r.Len()) {
+		err = fmt.Errorf("invalid page count %v", pages)
+	}
+
+	s.Pages = make(map[int][]int)
+	for i := 0; i < pages && err == nil; i++ {
+		index := get()
+		page := getSlice()
+		if err == nil && len(page) != pageSize {
+			err = fmt.Errorf("invalid page size %v", len(page))
+		}
+		s.Pages[index] = page
+	}
+
+	s.Input = getSlice()
+	s.Output = getSlice()
+
+	flags := get()
+	s.Halted = flags&1 != 0
+	if flags&2 != 0 {
+		f := &Fault{Position: get(), Op: get()}
+		n := get()
+		if err == nil && (n < 0 || n > r.Len()) {
+			err = fmt.Errorf("invalid length %v", n)
+		}
+
+		if err == nil {
+			reason := make([]byte, n)
+			r.Read(reason)
+			f.Reason = string(reason)
+		}
+		s.Fault = f
+	}
+
+	if err != nil {
+		return fmt.Errorf("corrupt snapshot: %s", err.Error())
+	}
+
+	return nil
+}
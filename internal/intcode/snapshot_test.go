@@ -0,0 +1,208 @@
+package intcode
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// runToEnd feeds inputs as they are requested and collects every output
+func runToEnd(m *Machine, inputs []int) ([]int, Status) {
+	outputs := []int{}
+	for {
+		status, _ := m.Run()
+		switch status {
+		case NeedsInput:
+			if len(inputs) == 0 {
+				return outputs, status
+			}
+
+			m.Feed(inputs[0])
+			inputs = inputs[1:]
+		case HasOutput:
+			v, _ := m.Output()
+			outputs = append(outputs, v)
+		default:
+			for v, ok := m.Output(); ok; v, ok = m.Output() {
+				outputs = append(outputs, v)
+			}
+			return outputs, status
+		}
+	}
+}
+
+func TestSnapshot_Restore(t *testing.T) {
+	// stores two inputs far past the end of the program and outputs their sum
+	codes := []int{109, 5000, 203, 0, 203, 1, 22201, 0, 1, 2, 204, 2, 99}
+	encodings := []string{"json", "binary"}
+
+	for _, encoding := range encodings {
+		m := NewMachine(codes)
+		m.Feed(3)
+		runToEnd(m, []int{})
+
+		var restored *Machine
+		var err error
+		switch encoding {
+		case "json":
+			var data []byte
+			data, err = json.Marshal(m.Snapshot())
+			if err != nil {
+				break
+			}
+
+			s := &Snapshot{}
+			err = json.Unmarshal(data, s)
+			if err != nil {
+				break
+			}
+			restored, err = RestoreMachine(s)
+		case "binary":
+			var data []byte
+			data, err = m.Snapshot().MarshalBinary()
+			if err != nil {
+				break
+			}
+
+			s := &Snapshot{}
+			err = s.UnmarshalBinary(data)
+			if err != nil {
+				break
+			}
+			restored, err = RestoreMachine(s)
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error returned for %s: %s", encoding, err.Error())
+			continue
+		}
+
+		original, _ := runToEnd(m, []int{4})
+		result, status := runToEnd(restored, []int{4})
+		if status != Halted {
+			t.Errorf("incorrect status %v for %s; expected %v", status, encoding, Halted)
+		}
+
+		if len(result) != 1 || len(original) != 1 || result[0] != original[0] || result[0] != 7 {
+			t.Errorf("incorrect output %v for %s; expected %v", result, encoding, original)
+		}
+
+		if restored.Snapshot().Steps != m.Snapshot().Steps {
+			t.Errorf("incorrect steps %v for %s; expected %v", restored.Snapshot().Steps, encoding, m.Snapshot().Steps)
+		}
+	}
+}
+
+func TestSnapshot_PendingOutput(t *testing.T) {
+	m := NewMachine([]int{104, 1, 104, 2, 99})
+	m.Run()
+
+	restored, err := RestoreMachine(m.Snapshot())
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	result, status := runToEnd(restored, []int{})
+	expected := []int{1, 2}
+	if status != Halted || len(result) != len(expected) || result[0] != expected[0] || result[1] != expected[1] {
+		t.Errorf("incorrect output %v with status %v; expected %v", result, status, expected)
+	}
+}
+
+func TestSnapshot_Halted(t *testing.T) {
+	inputs := [][]int{
+		[]int{99},
+		[]int{42}}
+	expected := []Status{Halted, Failed}
+
+	for i, input := range inputs {
+		m := NewMachine(input)
+		m.Run()
+
+		data, _ := m.Snapshot().MarshalBinary()
+		s := &Snapshot{}
+		err := s.UnmarshalBinary(data)
+		if err != nil {
+			t.Errorf("unexpected error returned: %s", err.Error())
+			continue
+		}
+
+		restored, _ := RestoreMachine(s)
+		status, _ := restored.Run()
+		if status != expected[i] {
+			t.Errorf("incorrect status %v; expected %v", status, expected[i])
+		}
+	}
+}
+
+func TestSnapshot_Corrupt(t *testing.T) {
+	data, _ := NewMachine([]int{3, 0, 99}).Snapshot().MarshalBinary()
+	inputs := [][]byte{
+		[]byte("not a snapshot"),
+		data[:len(data)-3],
+		append(append([]byte{}, snapshotMagic...), 0x7f)}
+
+	for i, input := range inputs {
+		s := &Snapshot{}
+		if s.UnmarshalBinary(input) == nil {
+			t.Errorf("expected error for input %v", i+1)
+		}
+	}
+}
+
+func TestSnapshot_FaultJSON(t *testing.T) {
+	m := NewMachine([]int{42})
+	m.Run()
+
+	data, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	expected := `"fault":{"position":0,"op":42,"reason":`
+	if !strings.Contains(string(data), expected) {
+		t.Errorf("incorrect snapshot %s; expected it to contain %s", data, expected)
+	}
+}
+
+func TestSnapshot_Arithmetic(t *testing.T) {
+	codes := []int{1102, 4611686018427387904, 4, 9, 4, 9, 99}
+
+	m := NewMachine(codes)
+	m.SetArithmetic(CheckedArithmetic)
+
+	data, _ := m.Snapshot().MarshalBinary()
+	s := &Snapshot{}
+	err := s.UnmarshalBinary(data)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	restored, err := RestoreMachine(s)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	if status, _ := restored.Run(); status != Failed {
+		t.Errorf("incorrect status %v; expected %v", status, Failed)
+	}
+
+	s.Arithmetic = Arithmetic(7)
+	if _, err := RestoreMachine(s); err == nil {
+		t.Errorf("expected error restoring unknown arithmetic")
+	}
+}
+
+func TestSnapshot_UnknownVersion(t *testing.T) {
+	data, _ := NewMachine([]int{104, 7, 99}).Snapshot().MarshalBinary()
+	data[len(snapshotMagic)] = snapshotVersion + 1
+
+	s := &Snapshot{}
+	if err := s.UnmarshalBinary(data); err == nil {
+		t.Errorf("expected error reading snapshot version %v", snapshotVersion+1)
+	}
+}
@@ -0,0 +1,444 @@
+package intcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoOutput is returned when the output node of a topology never wrote a
+// value
+var ErrNoOutput = errors.New("output node produced no value")
+
+// Stall is a node waiting for input that can never arrive
+type Stall struct {
+	Node     int
+	Position int
+}
+
+// DeadlockError is returned when every running node of a topology is waiting
+// for input
+type DeadlockError struct {
+	Stalls []Stall
+}
+
+// Error ...
+func (e *DeadlockError) Error() string {
+	stalls := make([]string, len(e.Stalls))
+	for i, s := range e.Stalls {
+		stalls[i] = fmt.Sprintf("node %v waiting for input at ip %v", s.Node, s.Position)
+	}
+
+	return fmt.Sprintf("deadlock: %s", strings.Join(stalls, ", "))
+}
+
+// NodeStats describes how a node of a topology ran. Duration is the time
+// from the start of the run until the node stopped.
+type NodeStats struct {
+	Inputs   int
+	Outputs  int
+	Steps    int
+	Status   Status
+	Fault    *Fault
+	Duration time.Duration
+}
+
+// Emission is a value written by a node. Index counts the node's values from
+// one, so in a ring it is the feedback round; Elapsed is measured from the
+// start of the run.
+type Emission struct {
+	Node    int
+	Index   int
+	Value   int
+	Elapsed time.Duration
+}
+
+// Topology connects copies of programs so the outputs of one machine become
+// the inputs of others. Every value a node outputs is delivered to each node
+// it is connected to; values from several nodes connected to the same node
+// are read in the order they arrive.
+type Topology struct {
+	// Observe is called with every value written by a node, one call at a
+	// time
+	Observe func(e Emission)
+	nodes   []*topologyNode
+	output  int
+}
+
+type topologyNode struct {
+	program []int
+	// seed is read before any value from connected nodes
+	seed []int
+	next []int
+	prev int
+}
+
+// NewTopology ...
+func NewTopology() *Topology {
+	return &Topology{output: -1}
+}
+
+// AddNode adds a machine running a copy of program that first reads the seed
+// values, such as a phase setting. It returns the node's id.
+func (t *Topology) AddNode(program []int, seed ...int) int {
+	t.nodes = append(t.nodes, &topologyNode{program: program, seed: append([]int{}, seed...)})
+	return len(t.nodes) - 1
+}
+
+// Input queues values for a node after those already given to it
+func (t *Topology) Input(node int, values ...int) {
+	t.nodes[node].seed = append(t.nodes[node].seed, values...)
+}
+
+// Connect sends the output of from to the input of to
+func (t *Topology) Connect(from, to int) {
+	t.nodes[from].next = append(t.nodes[from].next, to)
+	t.nodes[to].prev++
+}
+
+// Chain connects each node to the one after it
+func (t *Topology) Chain(nodes ...int) {
+	for i := 1; i < len(nodes); i++ {
+		t.Connect(nodes[i-1], nodes[i])
+	}
+}
+
+// Ring chains the nodes and connects the last back to the first
+func (t *Topology) Ring(nodes ...int) {
+	t.Chain(nodes...)
+	if len(nodes) > 0 {
+		t.Connect(nodes[len(nodes)-1], nodes[0])
+	}
+}
+
+// FanOut sends the output of from to every node in to
+func (t *Topology) FanOut(from int, to ...int) {
+	for _, n := range to {
+		t.Connect(from, n)
+	}
+}
+
+// FanIn sends the output of every node in from to to
+func (t *Topology) FanIn(to int, from ...int) {
+	for _, n := range from {
+		t.Connect(n, to)
+	}
+}
+
+// SetOutput selects the node whose last output value Run returns. It defaults
+// to the last node added.
+func (t *Topology) SetOutput(node int) {
+	t.output = node
+}
+
+// NewChain returns copies of program chained in order, each seeded with its
+// phase and the first also with input
+func NewChain(program []int, phases []int, input ...int) *Topology {
+	t := NewTopology()
+	ids := make([]int, len(phases))
+	for i, p := range phases {
+		ids[i] = t.AddNode(program, p)
+	}
+
+	t.Chain(ids...)
+	if len(ids) > 0 {
+		t.Input(ids[0], input...)
+	}
+
+	return t
+}
+
+// NewRing is NewChain with the last node feeding back into the first
+func NewRing(program []int, phases []int, input ...int) *Topology {
+	t := NewChain(program, phases, input...)
+	if len(phases) > 0 {
+		t.Connect(len(phases)-1, 0)
+	}
+
+	return t
+}
+
+// Run starts every node and waits for all of them to stop. A node that needs
+// input once every node connected to it has stopped is stopped as well. When
+// every running node is waiting for input the run is stopped with a
+// *DeadlockError. It returns the last value written by the output node once
+// every goroutine it started has exited.
+func (t *Topology) Run(ctx context.Context) (int, []NodeStats, error) {
+	out := t.output
+	if out < 0 {
+		out = len(t.nodes) - 1
+	}
+
+	if out < 0 || out >= len(t.nodes) {
+		return 0, nil, errors.New("topology has no output node")
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	mon := &monitor{running: len(t.nodes), positions: make([]int, len(t.nodes)), waiting: make([]bool, len(t.nodes)), cancel: cancel}
+	boxes := make([]*mailbox, len(t.nodes))
+	for i, n := range t.nodes {
+		boxes[i] = newMailbox(ctx, n.seed, n.prev)
+		boxes[i].node = i
+		boxes[i].mon = mon
+	}
+
+	// wake every waiting node when the run is cancelled
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		<-ctx.Done()
+		for _, b := range boxes {
+			b.close()
+		}
+	}()
+
+	start := time.Now()
+	var observed sync.Mutex
+	observe := func(e Emission) {
+		observed.Lock()
+		defer observed.Unlock()
+
+		e.Elapsed = time.Since(start)
+		t.Observe(e)
+	}
+
+	stats := make([]NodeStats, len(t.nodes))
+	writers := make([]*topologyWriter, len(t.nodes))
+	var wg sync.WaitGroup
+	for i, n := range t.nodes {
+		writers[i] = &topologyWriter{node: i, boxes: make([]*mailbox, len(n.next))}
+		if t.Observe != nil {
+			writers[i].observe = observe
+		}
+		for j, next := range n.next {
+			writers[i].boxes[j] = boxes[next]
+		}
+
+		wg.Add(1)
+		go func(i int, n *topologyNode) {
+			defer wg.Done()
+
+			comp := NewInstruction(0, append([]int{}, n.program...))
+			boxes[i].comp = comp
+			comp.Input = boxes[i]
+			comp.Output = writers[i]
+			result := RunContext(ctx, comp, 0)
+
+			for _, b := range writers[i].boxes {
+				b.done()
+			}
+			mon.finish()
+
+			stats[i] = NodeStats{
+				Outputs:  writers[i].count,
+				Steps:    result.Steps,
+				Status:   result.Status,
+				Fault:    result.Fault,
+				Duration: time.Since(start)}
+		}(i, n)
+	}
+
+	wg.Wait()
+	cancel()
+	<-closed
+
+	err := mon.err()
+	for i := range stats {
+		stats[i].Inputs = boxes[i].reads
+		if stats[i].Status == Failed && err == nil {
+			err = fmt.Errorf("node %v: %s", i, stats[i].Fault.Error())
+		}
+	}
+
+	if err == nil {
+		err = parent.Err()
+	}
+
+	if err == nil && writers[out].count == 0 {
+		err = ErrNoOutput
+	}
+
+	return writers[out].last, stats, err
+}
+
+// mailbox is an unbounded input queue shared by the nodes writing to it
+type mailbox struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ctx     context.Context
+	values  []int
+	writers int
+	reads   int
+	node    int
+	comp    *Instruction
+	mon     *monitor
+	// waiting is true while the reader is counted as blocked by mon
+	waiting bool
+}
+
+func newMailbox(ctx context.Context, seed []int, writers int) *mailbox {
+	b := &mailbox{ctx: ctx, values: append([]int{}, seed...), writers: writers}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// ReadValue waits for a value. It returns io.EOF once the queue is empty and
+// every writer has stopped.
+func (b *mailbox) ReadValue() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for len(b.values) == 0 && b.writers > 0 && b.ctx.Err() == nil {
+		if !b.waiting && b.mon != nil {
+			b.waiting = true
+			b.mon.block(b.node, b.comp.Position)
+		}
+		b.cond.Wait()
+	}
+	b.wake()
+
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(b.values) == 0 {
+		return 0, io.EOF
+	}
+
+	v := b.values[0]
+	b.values = b.values[1:]
+	b.reads++
+	return v, nil
+}
+
+// wake stops counting the reader as blocked; the caller holds the lock
+func (b *mailbox) wake() {
+	if b.waiting {
+		b.waiting = false
+		b.mon.unblock(b.node)
+	}
+}
+
+func (b *mailbox) put(v int) {
+	b.mu.Lock()
+	b.values = append(b.values, v)
+	b.wake()
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// done records that a writer has stopped
+func (b *mailbox) done() {
+	b.mu.Lock()
+	b.writers--
+	if b.writers == 0 {
+		b.wake()
+	}
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// close wakes every reader so it can notice cancellation. Holding the lock
+// while broadcasting means a reader has either not yet checked the context or
+// is already waiting.
+func (b *mailbox) close() {
+	b.mu.Lock()
+	b.cond.Broadcast()
+	b.mu.Unlock()
+}
+
+// topologyWriter delivers each output value to every connected node
+type topologyWriter struct {
+	node    int
+	boxes   []*mailbox
+	count   int
+	last    int
+	observe func(e Emission)
+}
+
+// WriteValue ...
+func (w *topologyWriter) WriteValue(v int) error {
+	w.count++
+	w.last = v
+	if w.observe != nil {
+		w.observe(Emission{Node: w.node, Index: w.count, Value: v})
+	}
+	for _, b := range w.boxes {
+		b.put(v)
+	}
+
+	return nil
+}
+
+// monitor counts the nodes that are blocked on input to detect a deadlock
+type monitor struct {
+	mu        sync.Mutex
+	running   int
+	blocked   int
+	positions []int
+	waiting   []bool
+	deadlock  *DeadlockError
+	cancel    context.CancelFunc
+}
+
+func (m *monitor) block(node int, position int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blocked++
+	m.positions[node] = position
+	m.waiting[node] = true
+	m.check()
+}
+
+func (m *monitor) unblock(node int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blocked--
+	m.waiting[node] = false
+}
+
+// finish records that a node has stopped
+func (m *monitor) finish() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running--
+	m.check()
+}
+
+// check stops the run if every running node is blocked; the caller holds the
+// lock
+func (m *monitor) check() {
+	if m.deadlock != nil || m.running == 0 || m.blocked < m.running {
+		return
+	}
+
+	m.deadlock = &DeadlockError{}
+	for node, waiting := range m.waiting {
+		if waiting {
+			m.deadlock.Stalls = append(m.deadlock.Stalls, Stall{Node: node, Position: m.positions[node]})
+		}
+	}
+	m.cancel()
+}
+
+func (m *monitor) err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.deadlock == nil {
+		return nil
+	}
+
+	return m.deadlock
+}
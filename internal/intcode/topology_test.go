@@ -0,0 +1,187 @@
+package intcode
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestNewChain(t *testing.T) {
+	codes := []int{3, 15, 3, 16, 1002, 16, 10, 16, 1, 16, 15, 15, 4, 15, 99, 0, 0}
+
+	signal, stats, err := NewChain(codes, []int{4, 3, 2, 1, 0}, 0).Run(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	if signal != 43210 {
+		t.Errorf("incorrect signal %v; expected %v", signal, 43210)
+	}
+
+	for i, s := range stats {
+		if s.Status != Halted || s.Inputs != 2 || s.Outputs != 1 || s.Steps != 6 {
+			t.Errorf("incorrect stats %+v for node %v", s, i)
+		}
+	}
+}
+
+func TestNewRing(t *testing.T) {
+	codes := []int{3, 26, 1001, 26, -4, 26, 3, 27, 1002, 27, 2, 27, 1, 27, 26, 27, 4, 27, 1001, 28, -1, 28, 1005, 28, 6, 99, 0, 0, 5}
+
+	signal, stats, err := NewRing(codes, []int{9, 8, 7, 6, 5}, 0).Run(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	if signal != 139629729 {
+		t.Errorf("incorrect signal %v; expected %v", signal, 139629729)
+	}
+
+	for i, s := range stats {
+		if s.Status != Halted || s.Outputs != 5 {
+			t.Errorf("incorrect stats %+v for node %v", s, i)
+		}
+	}
+}
+
+func TestTopology_Fan(t *testing.T) {
+	source := []int{3, 9, 4, 9, 99, 0, 0, 0, 0, 0}
+	// reads a value and outputs it doubled
+	double := []int{3, 9, 1002, 9, 2, 9, 4, 9, 99, 0}
+	// reads two values and outputs their sum
+	sum := []int{3, 11, 3, 12, 1, 11, 12, 11, 4, 11, 99, 0, 0}
+
+	top := NewTopology()
+	s := top.AddNode(source, 21)
+	a := top.AddNode(double)
+	b := top.AddNode(double)
+	c := top.AddNode(sum)
+	top.FanOut(s, a, b)
+	top.FanIn(c, a, b)
+
+	signal, stats, err := top.Run(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	if signal != 84 {
+		t.Errorf("incorrect signal %v; expected %v", signal, 84)
+	}
+
+	if stats[c].Inputs != 2 || stats[s].Outputs != 1 {
+		t.Errorf("incorrect stats %+v", stats)
+	}
+
+	top.SetOutput(a)
+	signal, _, _ = top.Run(context.Background())
+	if signal != 42 {
+		t.Errorf("incorrect signal %v from selected output; expected %v", signal, 42)
+	}
+}
+
+func TestTopology_Deadlock(t *testing.T) {
+	// both nodes wait for each other forever
+	top := NewTopology()
+	top.Ring(top.AddNode([]int{3, 0, 4, 0, 99}), top.AddNode([]int{1101, 0, 0, 0, 3, 0, 4, 0, 99}))
+
+	before := runtime.NumGoroutine()
+	_, stats, err := top.Run(context.Background())
+
+	d, ok := err.(*DeadlockError)
+	if !ok {
+		t.Errorf("incorrect error %v; expected a *DeadlockError", err)
+		return
+	}
+
+	expected := []Stall{Stall{Node: 0, Position: 0}, Stall{Node: 1, Position: 4}}
+	if len(d.Stalls) != len(expected) || d.Stalls[0] != expected[0] || d.Stalls[1] != expected[1] {
+		t.Errorf("incorrect stalls %v; expected %v", d.Stalls, expected)
+	}
+
+	for i, s := range stats {
+		if s.Status != Cancelled {
+			t.Errorf("incorrect status %v for node %v; expected %v", s.Status, i, Cancelled)
+		}
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("incorrect goroutine count %v after run; expected at most %v", after, before)
+	}
+}
+
+func TestTopology_Errors(t *testing.T) {
+	// the second node never reads its input
+	top := NewTopology()
+	top.Ring(top.AddNode([]int{3, 0, 4, 0, 99}), top.AddNode([]int{1105, 1, 0}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, stats, err := top.Run(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("incorrect error %v; expected %v", err, context.DeadlineExceeded)
+	}
+
+	for i, s := range stats {
+		if s.Status != Cancelled {
+			t.Errorf("incorrect status %v for node %v; expected %v", s.Status, i, Cancelled)
+		}
+	}
+
+	failed := NewChain([]int{3, 0, 55}, []int{1})
+	if _, _, err := failed.Run(context.Background()); err == nil {
+		t.Errorf("expected error from failed node")
+	}
+
+	silent := NewChain([]int{3, 0, 99}, []int{1})
+	if _, _, err := silent.Run(context.Background()); err != ErrNoOutput {
+		t.Errorf("incorrect error %v; expected %v", err, ErrNoOutput)
+	}
+}
+
+func TestTopology_Observe(t *testing.T) {
+	codes := []int{3, 26, 1001, 26, -4, 26, 3, 27, 1002, 27, 2, 27, 1, 27, 26, 27, 4, 27, 1001, 28, -1, 28, 1005, 28, 6, 99, 0, 0, 5}
+	top := NewRing(codes, []int{9, 8, 7, 6, 5}, 0)
+
+	emissions := []Emission{}
+	top.Observe = func(e Emission) {
+		emissions = append(emissions, e)
+	}
+
+	signal, stats, err := top.Run(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	if len(emissions) != 25 {
+		t.Errorf("incorrect emission count %v; expected %v", len(emissions), 25)
+		return
+	}
+
+	// every value follows the one it was computed from
+	for i, e := range emissions {
+		expected := Emission{Node: i % 5, Index: i/5 + 1}
+		if e.Node != expected.Node || e.Index != expected.Index {
+			t.Errorf("incorrect emission %+v at %v; expected node %v round %v", e, i, expected.Node, expected.Index)
+		}
+
+		if i > 0 && e.Elapsed < emissions[i-1].Elapsed {
+			t.Errorf("emission %v observed out of order", i)
+		}
+	}
+
+	if emissions[24].Value != signal {
+		t.Errorf("incorrect last emission %v; expected %v", emissions[24].Value, signal)
+	}
+
+	for i, s := range stats {
+		if s.Duration <= 0 {
+			t.Errorf("missing duration for node %v", i)
+		}
+	}
+}
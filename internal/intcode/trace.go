@@ -0,0 +1,132 @@
+package intcode
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// Tracer is notified before and after every executed instruction. The same
+// event is passed to both calls; After sees it completed with the memory
+// writes, relative base and error of the instruction.
+type Tracer interface {
+	Before(e *TraceEvent)
+	After(e *TraceEvent)
+}
+
+// MemoryWrite ...
+type MemoryWrite struct {
+	Address int `json:"address"`
+	Value   int `json:"value"`
+}
+
+// TraceEvent describes a single executed instruction. Operands holds the
+// value read for each input parameter and the target address for each
+// parameter that is written to.
+type TraceEvent struct {
+	Step         int             `json:"step"`
+	Position     int             `json:"ip"`
+	Op           OpCode          `json:"op"`
+	Mnemonic     string          `json:"mnemonic"`
+	Modes        []ParameterMode `json:"modes"`
+	Operands     []int           `json:"operands"`
+	Writes       []MemoryWrite   `json:"writes,omitempty"`
+	RelBase      int             `json:"rb"`
+	RelBaseAfter int             `json:"rb_after"`
+	Error        string          `json:"error,omitempty"`
+}
+
+func (i *Instruction) traceEvent() *TraceEvent {
+	m, _ := i.ops().Mnemonic(i.Op)
+	e := &TraceEvent{
+		Step:     i.executed + 1,
+		Position: i.Position,
+		Op:       i.Op,
+		Mnemonic: m,
+		Modes:    make([]ParameterMode, len(i.Parameters)),
+		Operands: make([]int, len(i.Parameters)),
+		RelBase:  i.RelPos}
+
+	for j, p := range i.Parameters {
+		e.Modes[j] = i.Modes[j]
+
+		if !i.ops().writesTo(i.Op, j) {
+			e.Operands[j], _ = i.getValue(j)
+			continue
+		}
+
+		switch i.Modes[j] {
+		case ImmediateMode:
+			e.Operands[j] = p.Position
+		case RelativeMode:
+			e.Operands[j] = i.RelPos + p.Value
+		default:
+			e.Operands[j] = p.Value
+		}
+	}
+
+	return e
+}
+
+// JSONTracer writes one JSON object per executed instruction
+type JSONTracer struct {
+	encoder *json.Encoder
+	err     error
+}
+
+// NewJSONTracer ...
+func NewJSONTracer(w io.Writer) *JSONTracer {
+	return &JSONTracer{encoder: json.NewEncoder(w)}
+}
+
+// Before ...
+func (t *JSONTracer) Before(e *TraceEvent) {}
+
+// After ...
+func (t *JSONTracer) After(e *TraceEvent) {
+	if t.err != nil {
+		return
+	}
+
+	t.err = t.encoder.Encode(e)
+}
+
+// Err returns the first error encountered writing the trace
+func (t *JSONTracer) Err() error {
+	return t.err
+}
+
+// TraceFile is a JSONTracer writing to a file through a buffer. It is what
+// the -trace flag of the intcode commands writes to.
+type TraceFile struct {
+	*JSONTracer
+	file   *os.File
+	buffer *bufio.Writer
+}
+
+// CreateTraceFile creates or truncates the file at path
+func CreateTraceFile(path string) (*TraceFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := bufio.NewWriter(file)
+	return &TraceFile{JSONTracer: NewJSONTracer(buffer), file: file, buffer: buffer}, nil
+}
+
+// Close flushes the trace and closes the file. It returns the first error
+// encountered writing the trace.
+func (t *TraceFile) Close() error {
+	err := t.Err()
+	if err == nil {
+		err = t.buffer.Flush()
+	}
+
+	if cerr := t.file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
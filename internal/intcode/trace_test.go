@@ -0,0 +1,158 @@
+package intcode
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type recordingTracer struct {
+	before []TraceEvent
+	after  []TraceEvent
+}
+
+func (r *recordingTracer) Before(e *TraceEvent) {
+	r.before = append(r.before, *e)
+}
+
+func (r *recordingTracer) After(e *TraceEvent) {
+	r.after = append(r.after, *e)
+}
+
+func TestTracer(t *testing.T) {
+	set := []int{109, 5, 21101, 2, 3, 4, 204, 4, 99}
+	expected := []TraceEvent{
+		TraceEvent{Step: 1, Position: 0, Op: RelativeBase, Operands: []int{5}, RelBase: 0, RelBaseAfter: 5},
+		TraceEvent{Step: 2, Position: 2, Op: AddOp, Operands: []int{2, 3, 9}, Writes: []MemoryWrite{MemoryWrite{Address: 9, Value: 5}}, RelBase: 5, RelBaseAfter: 5},
+		TraceEvent{Step: 3, Position: 6, Op: OutputOp, Operands: []int{5}, RelBase: 5, RelBaseAfter: 5},
+		TraceEvent{Step: 4, Position: 8, Op: TerminateOp, Operands: []int{}, RelBase: 5, RelBaseAfter: 5}}
+
+	tracer := &recordingTracer{}
+	comp := NewInstruction(0, set)
+	comp.Input = NewTextReader(strings.NewReader(""))
+	comp.Output = NewTextWriter(new(bytes.Buffer))
+	comp.Tracer = tracer
+
+	Run(comp)
+
+	if len(tracer.after) != len(expected) {
+		t.Errorf("incorrect number of events %v; expected %v", len(tracer.after), len(expected))
+	}
+
+	if len(tracer.before) != len(tracer.after) {
+		t.Errorf("unbalanced tracer calls %v before and %v after", len(tracer.before), len(tracer.after))
+	}
+
+	for i, e := range expected {
+		if i >= len(tracer.after) {
+			t.Errorf("missing event %v", i+1)
+			break
+		}
+
+		a := tracer.after[i]
+		if a.Step != e.Step || a.Position != e.Position || a.Op != e.Op || a.RelBase != e.RelBase || a.RelBaseAfter != e.RelBaseAfter {
+			t.Errorf("incorrect event %+v; expected %+v", a, e)
+		}
+
+		if len(a.Operands) != len(e.Operands) {
+			t.Errorf("incorrect operands %v; expected %v", a.Operands, e.Operands)
+		} else {
+			for j, o := range e.Operands {
+				if a.Operands[j] != o {
+					t.Errorf("incorrect operands %v; expected %v", a.Operands, e.Operands)
+					break
+				}
+			}
+		}
+
+		if len(a.Writes) != len(e.Writes) {
+			t.Errorf("incorrect writes %v; expected %v", a.Writes, e.Writes)
+		} else {
+			for j, w := range e.Writes {
+				if a.Writes[j] != w {
+					t.Errorf("incorrect writes %v; expected %v", a.Writes, e.Writes)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestTracer_Machine(t *testing.T) {
+	tracer := &recordingTracer{}
+	m := NewMachine([]int{1101, 1, 1, 0, 99})
+	m.SetTracer(tracer)
+	m.Run()
+
+	if len(tracer.after) != 2 || tracer.after[1].Op != TerminateOp || tracer.after[1].Step != 2 {
+		t.Errorf("incorrect events %+v; expected the halt as step %v", tracer.after, 2)
+	}
+}
+
+func TestJSONTracer(t *testing.T) {
+	set := []int{3, 0, 4, 0, 99}
+	expected := []string{
+		`{"step":1,"ip":0,"op":3,"mnemonic":"in","modes":[0],"operands":[0],"writes":[{"address":0,"value":7}],"rb":0,"rb_after":0}`,
+		`{"step":2,"ip":2,"op":4,"mnemonic":"out","modes":[0],"operands":[7],"rb":0,"rb_after":0}`,
+		`{"step":3,"ip":4,"op":99,"mnemonic":"hlt","modes":[],"operands":[],"rb":0,"rb_after":0}`}
+
+	buf := new(bytes.Buffer)
+	tracer := NewJSONTracer(buf)
+	comp := NewInstruction(0, set)
+	comp.Input = NewTextReader(strings.NewReader("7\n"))
+	comp.Output = NewTextWriter(new(bytes.Buffer))
+	comp.Tracer = tracer
+
+	Run(comp)
+
+	if tracer.Err() != nil {
+		t.Errorf("unexpected error returned: %s", tracer.Err().Error())
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(expected) {
+		t.Errorf("incorrect number of trace lines %v; expected %v", len(lines), len(expected))
+		return
+	}
+
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("incorrect trace line %s; expected %s", line, expected[i])
+		}
+	}
+}
+
+func TestTraceFile(t *testing.T) {
+	dir, err := os.MkdirTemp("", "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "trace.jsonl")
+	tracer, err := CreateTraceFile(path)
+	if err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+		return
+	}
+
+	comp := NewInstruction(0, []int{104, 1, 99})
+	comp.Output = NewTextWriter(new(bytes.Buffer))
+	comp.Tracer = tracer
+	Run(comp)
+
+	if err := tracer.Close(); err != nil {
+		t.Errorf("unexpected error returned: %s", err.Error())
+	}
+
+	text, _ := os.ReadFile(path)
+	if lines := strings.Count(string(text), "\n"); lines != 2 {
+		t.Errorf("incorrect number of trace lines %v; expected %v", lines, 2)
+	}
+
+	if _, err := CreateTraceFile(filepath.Join(dir, "missing", "trace.jsonl")); err == nil {
+		t.Errorf("expected error creating trace in a missing directory")
+	}
+}